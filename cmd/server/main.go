@@ -11,6 +11,7 @@ import (
 	"github.com/in-jun/github-profile-guestbook/internal/auth"
 	"github.com/in-jun/github-profile-guestbook/internal/config"
 	"github.com/in-jun/github-profile-guestbook/internal/db"
+	"github.com/in-jun/github-profile-guestbook/internal/filter"
 	"github.com/in-jun/github-profile-guestbook/internal/handler"
 	"github.com/in-jun/github-profile-guestbook/internal/middleware"
 	"github.com/in-jun/github-profile-guestbook/web"
@@ -32,6 +33,25 @@ func main() {
 		panic(fmt.Sprintf("failed to run migrations: %v", err))
 	}
 
+	if err := handler.LoadFallbackFonts(cfg.PNGFallbackFonts); err != nil {
+		panic(fmt.Sprintf("failed to load fallback fonts: %v", err))
+	}
+
+	if err := handler.SetReactionPalette(cfg.ReactionPalette); err != nil {
+		panic(fmt.Sprintf("failed to set reaction palette: %v", err))
+	}
+
+	contentFilter, err := filter.New(filter.Config{
+		MaxRepeats:        cfg.ContentMaxRepeats,
+		LinkPolicy:        cfg.ContentLinkPolicy,
+		WordList:          cfg.ContentWordList,
+		ClassifierURL:     cfg.ClassifierURL,
+		ClassifierTimeout: time.Duration(cfg.ClassifierTimeout) * time.Second,
+	})
+	if err != nil {
+		panic(fmt.Sprintf("failed to set up content filters: %v", err))
+	}
+
 	stateBytes := make([]byte, 32)
 	rand.Read(stateBytes)
 	oauthState := base64.URLEncoding.EncodeToString(stateBytes)
@@ -51,10 +71,29 @@ func main() {
 		AccessTokenTTL:  cfg.AccessTokenTTL,
 		RefreshTokenTTL: cfg.RefreshTokenTTL,
 	})
+	renderCache := handler.NewRenderCache(time.Duration(cfg.RenderCacheTTL) * time.Second)
+
 	userHandler := handler.NewUserHandler(database)
-	messageHandler := handler.NewMessageHandler(database)
-	likeHandler := handler.NewLikeHandler(database)
-	svgHandler := handler.NewSVGHandler(database)
+	messageHandler := handler.NewMessageHandler(database, renderCache, &handler.MessageHandlerConfig{
+		EditWindow:    cfg.MessageEditWindow,
+		ContentFilter: contentFilter,
+	})
+	likeHandler := handler.NewLikeHandler(database, renderCache)
+	reactionHandler := handler.NewReactionHandler(database, renderCache)
+	replyHandler := handler.NewReplyHandler(database, renderCache)
+	moderationHandler := handler.NewModerationHandler(database, renderCache)
+	blockHandler := handler.NewBlockHandler(database, renderCache)
+	pinHandler := handler.NewPinHandler(database, renderCache)
+	removalHandler := handler.NewRemovalHandler(database, renderCache)
+	reportHandler := handler.NewReportHandler(database)
+	adminHandler := handler.NewAdminHandler(database, renderCache, &handler.AdminHandlerConfig{
+		AdminGitHubIDs: cfg.AdminGitHubIDs,
+	})
+	svgHandler := handler.NewSVGHandler(database, renderCache, &handler.SVGHandlerConfig{
+		AvatarsURL:     cfg.GitHubAvatarsURL,
+		AvatarCacheTTL: cfg.AvatarCacheTTL,
+	})
+	settingsHandler := handler.NewSettingsHandler(database, renderCache)
 
 	router := gin.Default()
 	router.Use(auth.AuthMiddleware(database, jwtSecret, cfg.AccessTokenTTL, cfg.RefreshTokenTTL))
@@ -68,8 +107,59 @@ func main() {
 		{
 			user.POST("/:username/messages", postLimiter.Handler(), messageHandler.Create)
 			user.GET("/:username/messages", getLimiter.Handler(), messageHandler.List)
+			user.PATCH("/:username/messages", postLimiter.Handler(), messageHandler.Edit)
 			user.DELETE("/:username/messages", postLimiter.Handler(), messageHandler.Delete)
-			user.GET("/:username/svg", svgHandler.GetSVG)
+			user.GET("/:username/svg", getLimiter.Handler(), svgHandler.GetSVG)
+			user.GET("/:username/png", getLimiter.Handler(), svgHandler.GetPNG)
+		}
+
+		messages := api.Group("/messages")
+		{
+			messages.GET("/:messageID/revisions", getLimiter.Handler(), messageHandler.Revisions)
+			messages.POST("/:messageID/reply", postLimiter.Handler(), replyHandler.Create)
+			messages.PATCH("/:messageID/reply", postLimiter.Handler(), replyHandler.Edit)
+			messages.DELETE("/:messageID/reply", postLimiter.Handler(), replyHandler.Delete)
+			messages.POST("/:messageID/report", postLimiter.Handler(), reportHandler.Create)
+			messages.GET("/:messageID/reactions", getLimiter.Handler(), reactionHandler.List)
+			messages.PUT("/:messageID/reactions/:emoji", postLimiter.Handler(), reactionHandler.Add)
+			messages.DELETE("/:messageID/reactions/:emoji", postLimiter.Handler(), reactionHandler.Remove)
+			messages.DELETE("/:messageID", postLimiter.Handler(), removalHandler.Delete)
+			messages.POST("/bulk-delete", postLimiter.Handler(), removalHandler.BulkDelete)
+		}
+
+		api.GET("/removals", getLimiter.Handler(), removalHandler.List)
+
+		settings := api.Group("/settings")
+		{
+			settings.GET("", getLimiter.Handler(), settingsHandler.Get)
+			settings.PUT("", postLimiter.Handler(), settingsHandler.Update)
+		}
+
+		moderation := api.Group("/moderation")
+		{
+			moderation.GET("/pending", getLimiter.Handler(), moderationHandler.Pending)
+			moderation.POST("/pending/:messageID/approve", postLimiter.Handler(), moderationHandler.Approve)
+			moderation.POST("/pending/:messageID/reject", postLimiter.Handler(), moderationHandler.Reject)
+		}
+
+		blocks := api.Group("/blocks")
+		{
+			blocks.GET("", getLimiter.Handler(), blockHandler.List)
+			blocks.PUT("/:username", postLimiter.Handler(), blockHandler.Block)
+			blocks.DELETE("/:username", postLimiter.Handler(), blockHandler.Unblock)
+		}
+
+		pins := api.Group("/pins")
+		{
+			pins.PUT("", postLimiter.Handler(), pinHandler.Reorder)
+		}
+
+		admin := api.Group("/admin")
+		{
+			admin.GET("/reports", getLimiter.Handler(), adminHandler.Reports)
+			admin.POST("/reports/:reportID/resolve", postLimiter.Handler(), adminHandler.Resolve)
+			admin.POST("/messages/:messageID/hide", postLimiter.Handler(), adminHandler.Hide)
+			admin.POST("/messages/:messageID/unhide", postLimiter.Handler(), adminHandler.Unhide)
 		}
 
 		authGroup := api.Group("/auth")
@@ -81,6 +171,7 @@ func main() {
 
 		like := api.Group("/like")
 		{
+			like.PUT("/:messageID", postLimiter.Handler(), likeHandler.Vote)
 			like.POST("/like/:messageID", postLimiter.Handler(), likeHandler.Like)
 			like.POST("/remove-like/:messageID", postLimiter.Handler(), likeHandler.RemoveLike)
 			like.POST("/dislike/:messageID", postLimiter.Handler(), likeHandler.Dislike)
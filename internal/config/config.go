@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
@@ -19,6 +20,18 @@ type Config struct {
 	JWTSecret          string
 	AccessTokenTTL     int
 	RefreshTokenTTL    int
+	GitHubAvatarsURL   string
+	AvatarCacheTTL     int
+	RenderCacheTTL     int
+	PNGFallbackFonts   []string
+	MessageEditWindow  int
+	ContentMaxRepeats  int
+	ContentLinkPolicy  string
+	ContentWordList    string
+	ClassifierURL      string
+	ClassifierTimeout  int
+	AdminGitHubIDs     []int64
+	ReactionPalette    []string
 }
 
 func Load() *Config {
@@ -35,6 +48,18 @@ func Load() *Config {
 		JWTSecret:          mustEnv("JWT_SECRET"),
 		AccessTokenTTL:     envInt("ACCESS_TOKEN_TTL", 900),
 		RefreshTokenTTL:    envInt("REFRESH_TOKEN_TTL", 604800),
+		GitHubAvatarsURL:   envWithDefault("GITHUB_AVATARS_URL", "https://avatars.githubusercontent.com"),
+		AvatarCacheTTL:     envInt("AVATAR_CACHE_TTL", 86400),
+		RenderCacheTTL:     envInt("RENDER_CACHE_TTL", 300),
+		PNGFallbackFonts:   envList("PNG_FALLBACK_FONTS"),
+		MessageEditWindow:  envInt("MESSAGE_EDIT_WINDOW", 86400),
+		ContentMaxRepeats:  envInt("CONTENT_MAX_REPEATS", 10),
+		ContentLinkPolicy:  envWithDefault("CONTENT_LINK_POLICY", "allow"),
+		ContentWordList:    os.Getenv("CONTENT_WORDLIST"),
+		ClassifierURL:      os.Getenv("CONTENT_CLASSIFIER_URL"),
+		ClassifierTimeout:  envInt("CONTENT_CLASSIFIER_TIMEOUT", 2),
+		AdminGitHubIDs:     envIDList("ADMIN_GITHUB_IDS"),
+		ReactionPalette:    envList("REACTION_PALETTE"),
 	}
 	return cfg
 }
@@ -59,6 +84,31 @@ func envInt(key string, defaultVal int) int {
 	return n
 }
 
+func envList(key string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// envIDList parses a comma-separated list of numeric IDs. Unlike the other
+// helpers it fails on bad values instead of ignoring them, since a typo
+// would silently lock an admin out.
+func envIDList(key string) []int64 {
+	var ids []int64
+	for _, v := range envList(key) {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			panic(fmt.Sprintf("environment variable %s: invalid ID %q", key, v))
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
 func envWithDefault(key, defaultVal string) string {
 	v := os.Getenv(key)
 	if v == "" {
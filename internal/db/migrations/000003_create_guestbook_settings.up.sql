@@ -0,0 +1,9 @@
+CREATE TABLE guestbook_settings (
+    user_id      BIGINT      PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    theme        TEXT        NOT NULL DEFAULT 'auto',
+    bg_color     TEXT,
+    text_color   TEXT,
+    border_color TEXT,
+    accent_color TEXT,
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1 @@
+ALTER TABLE guestbook_settings DROP COLUMN IF EXISTS max_messages;
@@ -0,0 +1 @@
+ALTER TABLE guestbook_settings ADD COLUMN max_messages INT CHECK (max_messages > 0);
@@ -0,0 +1 @@
+ALTER TABLE guestbook_settings DROP COLUMN IF EXISTS sort;
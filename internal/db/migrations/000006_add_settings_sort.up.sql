@@ -0,0 +1 @@
+ALTER TABLE guestbook_settings ADD COLUMN sort TEXT;
@@ -0,0 +1,10 @@
+ALTER TABLE messages ADD COLUMN edited_at TIMESTAMPTZ;
+
+CREATE TABLE message_revisions (
+    id         BIGSERIAL   PRIMARY KEY,
+    message_id BIGINT      NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    content    TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_message_revisions_message ON message_revisions (message_id);
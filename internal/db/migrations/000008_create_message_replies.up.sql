@@ -0,0 +1,6 @@
+CREATE TABLE message_replies (
+    message_id BIGINT      PRIMARY KEY REFERENCES messages(id) ON DELETE CASCADE,
+    content    TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
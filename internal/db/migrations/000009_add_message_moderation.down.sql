@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS approved_authors;
+
+DROP INDEX IF EXISTS idx_messages_pending;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS status;
+
+ALTER TABLE guestbook_settings DROP COLUMN IF EXISTS moderation;
@@ -0,0 +1,13 @@
+ALTER TABLE guestbook_settings ADD COLUMN moderation TEXT;
+
+ALTER TABLE messages ADD COLUMN status TEXT NOT NULL DEFAULT 'published'
+    CHECK (status IN ('published', 'pending'));
+
+CREATE INDEX idx_messages_pending ON messages (receiver_id) WHERE status = 'pending';
+
+CREATE TABLE approved_authors (
+    receiver_id BIGINT      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    author_id   BIGINT      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (receiver_id, author_id)
+);
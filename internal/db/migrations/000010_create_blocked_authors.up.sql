@@ -0,0 +1,7 @@
+CREATE TABLE blocked_authors (
+    receiver_id   BIGINT      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    author_id     BIGINT      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    hide_messages BOOLEAN     NOT NULL DEFAULT FALSE,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (receiver_id, author_id)
+);
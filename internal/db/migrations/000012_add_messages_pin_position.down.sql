@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_messages_pinned;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS pin_position;
@@ -0,0 +1,3 @@
+ALTER TABLE messages ADD COLUMN pin_position INTEGER;
+
+CREATE INDEX idx_messages_pinned ON messages (receiver_id, pin_position) WHERE pin_position IS NOT NULL;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS reports;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS hidden_by;
+ALTER TABLE messages DROP COLUMN IF EXISTS hidden_at;
@@ -0,0 +1,19 @@
+ALTER TABLE messages ADD COLUMN hidden_at TIMESTAMPTZ;
+ALTER TABLE messages ADD COLUMN hidden_by BIGINT REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE TABLE reports (
+    id          BIGSERIAL   PRIMARY KEY,
+    message_id  BIGINT      REFERENCES messages(id) ON DELETE SET NULL,
+    receiver_id BIGINT      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    author_id   BIGINT      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reporter_id BIGINT      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason      TEXT        NOT NULL,
+    content     TEXT        NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMPTZ,
+    resolved_by BIGINT      REFERENCES users(id) ON DELETE SET NULL,
+    resolution  TEXT        CHECK (resolution IN ('hidden', 'dismissed'))
+);
+
+CREATE UNIQUE INDEX idx_reports_open_reporter ON reports (message_id, reporter_id) WHERE resolved_at IS NULL;
+CREATE INDEX idx_reports_open ON reports (created_at) WHERE resolved_at IS NULL;
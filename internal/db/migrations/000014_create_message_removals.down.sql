@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS message_removals;
+
+DELETE FROM messages WHERE status = 'removed';
+ALTER TABLE messages DROP CONSTRAINT messages_status_check;
+ALTER TABLE messages ADD CONSTRAINT messages_status_check CHECK (status IN ('published', 'pending'));
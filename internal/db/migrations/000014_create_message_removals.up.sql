@@ -0,0 +1,14 @@
+ALTER TABLE messages DROP CONSTRAINT messages_status_check;
+ALTER TABLE messages ADD CONSTRAINT messages_status_check CHECK (status IN ('published', 'pending', 'removed'));
+
+CREATE TABLE message_removals (
+    id          BIGSERIAL   PRIMARY KEY,
+    message_id  BIGINT      REFERENCES messages(id) ON DELETE SET NULL,
+    receiver_id BIGINT      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    author_id   BIGINT      NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    content     TEXT        NOT NULL,
+    reason      TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_message_removals_receiver ON message_removals (receiver_id, created_at);
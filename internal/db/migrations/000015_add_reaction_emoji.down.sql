@@ -0,0 +1,17 @@
+ALTER TABLE guestbook_settings DROP COLUMN IF EXISTS reactions;
+
+-- Only likes and dislikes survive, and a user who did both keeps the like.
+DELETE FROM reactions WHERE emoji NOT IN ('👍', '👎');
+DELETE FROM reactions d
+WHERE d.emoji = '👎' AND EXISTS (
+    SELECT 1 FROM reactions l
+    WHERE l.message_id = d.message_id AND l.user_id = d.user_id AND l.emoji = '👍'
+);
+
+ALTER TABLE reactions DROP CONSTRAINT reactions_pkey;
+ALTER TABLE reactions ADD COLUMN type SMALLINT CHECK (type IN (1, -1));
+UPDATE reactions SET type = CASE emoji WHEN '👍' THEN 1 ELSE -1 END;
+ALTER TABLE reactions ALTER COLUMN type SET NOT NULL;
+ALTER TABLE reactions DROP COLUMN emoji;
+ALTER TABLE reactions DROP COLUMN created_at;
+ALTER TABLE reactions ADD PRIMARY KEY (message_id, user_id);
@@ -0,0 +1,10 @@
+ALTER TABLE reactions ADD COLUMN emoji TEXT;
+UPDATE reactions SET emoji = CASE type WHEN 1 THEN '👍' ELSE '👎' END;
+ALTER TABLE reactions ALTER COLUMN emoji SET NOT NULL;
+ALTER TABLE reactions ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+ALTER TABLE reactions DROP CONSTRAINT reactions_pkey;
+ALTER TABLE reactions DROP COLUMN type;
+ALTER TABLE reactions ADD PRIMARY KEY (message_id, user_id, emoji);
+
+ALTER TABLE guestbook_settings ADD COLUMN reactions TEXT[];
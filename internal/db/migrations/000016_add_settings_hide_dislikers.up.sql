@@ -0,0 +1 @@
+ALTER TABLE guestbook_settings ADD COLUMN hide_dislikers BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,73 @@
+package filter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultClassifierTimeout = 2 * time.Second
+	maxClassifierResponse    = 64 << 10
+)
+
+// Classifier asks an HTTP service, such as a local moderation model, for a
+// verdict on each message. The service is POSTed {"content": "..."} and
+// answers {"allow": true}, optionally with a rewritten "content", or
+// {"allow": false, "reason": "..."}.
+type Classifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewClassifier(url string, timeout time.Duration) *Classifier {
+	if timeout <= 0 {
+		timeout = defaultClassifierTimeout
+	}
+	return &Classifier{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (cl *Classifier) Filter(ctx context.Context, msg Message) (string, error) {
+	body, err := json.Marshal(map[string]string{"content": msg.Content})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cl.url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := cl.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("content classifier: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("content classifier: unexpected status %s", resp.Status)
+	}
+
+	var verdict struct {
+		Allow   bool    `json:"allow"`
+		Reason  string  `json:"reason"`
+		Content *string `json:"content"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxClassifierResponse)).Decode(&verdict); err != nil {
+		return "", fmt.Errorf("content classifier: %w", err)
+	}
+
+	if !verdict.Allow {
+		if verdict.Reason == "" {
+			return "", reject("Message was rejected by the content filter")
+		}
+		return "", reject(verdict.Reason)
+	}
+	if verdict.Content != nil {
+		return *verdict.Content, nil
+	}
+	return msg.Content, nil
+}
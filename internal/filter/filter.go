@@ -0,0 +1,119 @@
+// Package filter checks and cleans up guestbook content before it is stored.
+// Filters run in a chain; each one either passes the content on, possibly
+// rewritten, or rejects it with a reason that is shown to the author.
+package filter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Message is the content being filtered along with what filters may need to
+// know about the guestbook it is posted to.
+type Message struct {
+	Content string
+	// OwnerWords are the guestbook owner's blocked words.
+	OwnerWords []string
+}
+
+type ContentFilter interface {
+	// Filter returns the content to keep, or a *Rejection. Any other error
+	// means the content could not be checked.
+	Filter(ctx context.Context, msg Message) (string, error)
+}
+
+// Rejection refuses content. Reason is an English message that callers
+// translate for the author.
+type Rejection struct {
+	Reason string
+}
+
+func (r *Rejection) Error() string {
+	return r.Reason
+}
+
+func reject(reason string) error {
+	return &Rejection{Reason: reason}
+}
+
+// IsRejection reports whether err refuses the content, as opposed to the
+// content not being checked.
+func IsRejection(err error) bool {
+	var r *Rejection
+	return errors.As(err, &r)
+}
+
+// Chain runs filters in order, passing each the previous one's output.
+type Chain []ContentFilter
+
+func (ch Chain) Filter(ctx context.Context, msg Message) (string, error) {
+	for _, f := range ch {
+		content, err := f.Filter(ctx, msg)
+		if err != nil {
+			return "", err
+		}
+		msg.Content = content
+	}
+	return msg.Content, nil
+}
+
+// Link policies.
+const (
+	LinksAllow  = "allow"
+	LinksGitHub = "github"
+	LinksReject = "reject"
+)
+
+// Basic returns the filters every piece of content goes through: invisible
+// characters are stripped, and content must be non-empty, at most maxLength
+// characters after truncation and free of combining marks.
+func Basic(maxLength int) Chain {
+	return Chain{StripInvisible{}, Required{}, MaxLength(maxLength), Zalgo{}}
+}
+
+// Config selects the optional filters built by New.
+type Config struct {
+	// MaxRepeats is the longest run of one character that is kept; longer
+	// runs are shortened. Zero disables the filter.
+	MaxRepeats int
+	LinkPolicy string
+	// WordList is a file of globally blocked words, one per line.
+	WordList string
+	// ClassifierURL, if set, is sent every message for a verdict.
+	ClassifierURL     string
+	ClassifierTimeout time.Duration
+}
+
+// New builds the configurable filters for guestbook messages. Owners' blocked
+// words are always checked.
+func New(cfg Config) (Chain, error) {
+	var chain Chain
+
+	if cfg.MaxRepeats > 0 {
+		chain = append(chain, MaxRepeats(cfg.MaxRepeats))
+	}
+
+	switch cfg.LinkPolicy {
+	case "", LinksAllow:
+	case LinksGitHub, LinksReject:
+		chain = append(chain, Links{Policy: cfg.LinkPolicy})
+	default:
+		return nil, fmt.Errorf("unknown link policy %q", cfg.LinkPolicy)
+	}
+
+	var words []string
+	if cfg.WordList != "" {
+		var err error
+		if words, err = LoadWordList(cfg.WordList); err != nil {
+			return nil, err
+		}
+	}
+	chain = append(chain, NewProfanity(words))
+
+	if cfg.ClassifierURL != "" {
+		chain = append(chain, NewClassifier(cfg.ClassifierURL, cfg.ClassifierTimeout))
+	}
+	return chain, nil
+}
@@ -0,0 +1,40 @@
+package filter
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"]+`)
+
+// Links enforces a link policy: LinksReject refuses any link, LinksGitHub
+// only links to github.com.
+type Links struct {
+	Policy string
+}
+
+func (l Links) Filter(_ context.Context, msg Message) (string, error) {
+	for _, link := range linkPattern.FindAllString(msg.Content, -1) {
+		if l.Policy == LinksReject {
+			return "", reject("Links are not allowed")
+		}
+		if !isGitHubLink(link) {
+			return "", reject("Only GitHub links are allowed")
+		}
+	}
+	return msg.Content, nil
+}
+
+func isGitHubLink(link string) bool {
+	if !strings.Contains(link, "://") {
+		link = "https://" + link
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return host == "github.com" || strings.HasSuffix(host, ".github.com")
+}
@@ -0,0 +1,92 @@
+package filter
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// StripInvisible removes zero-width and bidirectional control characters,
+// which can hide text or make it display in a different order than it is
+// stored. Joiners are kept since emoji sequences and some scripts need them.
+type StripInvisible struct{}
+
+func (StripInvisible) Filter(_ context.Context, msg Message) (string, error) {
+	return strings.Map(func(r rune) rune {
+		if isInvisible(r) {
+			return -1
+		}
+		return r
+	}, msg.Content), nil
+}
+
+func isInvisible(r rune) bool {
+	switch {
+	case r == '\u00AD', r == '\u180E', r == '\uFEFF': // soft hyphen, Mongolian vowel separator, BOM
+		return true
+	case r == '\u200B', r == '\u200E', r == '\u200F': // zero-width space, LRM, RLM
+		return true
+	case r >= '\u202A' && r <= '\u202E': // bidi embeddings and overrides
+		return true
+	case r >= '\u2060' && r <= '\u2064', r >= '\u2066' && r <= '\u2069': // invisible operators, bidi isolates
+		return true
+	}
+	return false
+}
+
+// Required rejects empty content.
+type Required struct{}
+
+func (Required) Filter(_ context.Context, msg Message) (string, error) {
+	if strings.TrimSpace(msg.Content) == "" {
+		return "", reject("Content not provided")
+	}
+	return msg.Content, nil
+}
+
+// MaxLength truncates content to the given number of characters.
+type MaxLength int
+
+func (n MaxLength) Filter(_ context.Context, msg Message) (string, error) {
+	runes := []rune(msg.Content)
+	if len(runes) > int(n) {
+		return string(runes[:n]), nil
+	}
+	return msg.Content, nil
+}
+
+var combiningMarks = regexp.MustCompile(`[\p{Mn}\p{Me}\p{Mc}]`)
+
+// Zalgo rejects content containing combining marks, which can be stacked to
+// draw over neighbouring messages.
+type Zalgo struct{}
+
+func (Zalgo) Filter(_ context.Context, msg Message) (string, error) {
+	if combiningMarks.MatchString(msg.Content) {
+		return "", reject("Invalid content")
+	}
+	return msg.Content, nil
+}
+
+// MaxRepeats shortens runs of the same character to at most n, so
+// "woooooooooow" becomes "wooooow" with n = 5. Whitespace runs are left to
+// the renderers.
+type MaxRepeats int
+
+func (n MaxRepeats) Filter(_ context.Context, msg Message) (string, error) {
+	var b strings.Builder
+	prev, run := rune(-1), 0
+	for _, r := range msg.Content {
+		if r == prev {
+			run++
+		} else {
+			prev, run = r, 1
+		}
+		if run > int(n) && !unicode.IsSpace(r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,96 @@
+package filter
+
+import (
+	"context"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Profanity rejects content containing a globally blocked word or one of the
+// guestbook owner's blocked words. Matching ignores case.
+type Profanity struct {
+	words []string
+}
+
+func NewProfanity(words []string) Profanity {
+	return Profanity{words: NormalizeWords(words)}
+}
+
+func (p Profanity) Filter(_ context.Context, msg Message) (string, error) {
+	text := strings.ToLower(msg.Content)
+	for _, list := range [][]string{p.words, msg.OwnerWords} {
+		for _, word := range list {
+			if containsWord(text, strings.ToLower(word)) {
+				return "", reject("Message contains blocked words")
+			}
+		}
+	}
+	return msg.Content, nil
+}
+
+// LoadWordList reads a file of blocked words, one per line. Blank lines and
+// lines starting with # are skipped.
+func LoadWordList(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var words []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			words = append(words, line)
+		}
+	}
+	return words, nil
+}
+
+// NormalizeWords lowercases and trims words, dropping blanks and duplicates.
+func NormalizeWords(words []string) []string {
+	seen := make(map[string]bool, len(words))
+	normalized := make([]string, 0, len(words))
+	for _, w := range words {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if w == "" || seen[w] {
+			continue
+		}
+		seen[w] = true
+		normalized = append(normalized, w)
+	}
+	return normalized
+}
+
+// containsWord reports whether word appears in text other than as part of a
+// longer word. In scripts written without spaces between words it matches
+// anywhere.
+func containsWord(text, word string) bool {
+	if word == "" {
+		return false
+	}
+	first, _ := utf8.DecodeRuneInString(word)
+	last, _ := utf8.DecodeLastRuneInString(word)
+	for i := 0; i < len(text); {
+		j := strings.Index(text[i:], word)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(word)
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if !(continuesWord(before) && continuesWord(first)) && !(continuesWord(last) && continuesWord(after)) {
+			return true
+		}
+		i = start + utf8.RuneLen(first)
+	}
+	return false
+}
+
+// continuesWord reports whether r is part of a word in a script that
+// separates words with spaces.
+func continuesWord(r rune) bool {
+	if r == utf8.RuneError || unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+		return false
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/in-jun/github-profile-guestbook/internal/model"
+)
+
+// Report resolutions.
+const (
+	reportHidden    = "hidden"
+	reportDismissed = "dismissed"
+)
+
+var errUnknownReportStatus = errors.New("Unknown report status")
+
+// reportStatuses maps the status filter of the report list to its condition.
+var reportStatuses = map[string]string{
+	"open":     "r.resolved_at IS NULL",
+	"resolved": "r.resolved_at IS NOT NULL",
+	"all":      "TRUE",
+}
+
+// AdminHandler serves the site-wide moderation endpoints. Admins are the
+// users whose GitHub IDs are configured.
+type AdminHandler struct {
+	db     *sql.DB
+	cache  *RenderCache
+	admins map[int64]bool
+}
+
+type AdminHandlerConfig struct {
+	AdminGitHubIDs []int64
+}
+
+func NewAdminHandler(db *sql.DB, cache *RenderCache, cfg *AdminHandlerConfig) *AdminHandler {
+	admins := make(map[int64]bool, len(cfg.AdminGitHubIDs))
+	for _, id := range cfg.AdminGitHubIDs {
+		admins[id] = true
+	}
+	return &AdminHandler{db: db, cache: cache, admins: admins}
+}
+
+// requireAdmin writes an error response unless the caller is an admin.
+func (h *AdminHandler) requireAdmin(c *gin.Context) (int64, bool) {
+	userID, ok := requireAuth(c)
+	if !ok {
+		return 0, false
+	}
+	var githubID int64
+	if err := h.db.QueryRow("SELECT github_id FROM users WHERE id = $1", userID).Scan(&githubID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get user")})
+		return 0, false
+	}
+	if !h.admins[githubID] {
+		c.JSON(http.StatusForbidden, gin.H{"error": tr(c, "Admin access required")})
+		return 0, false
+	}
+	return userID, true
+}
+
+// Reports lists reports, newest first. status is open (the default),
+// resolved or all.
+func (h *AdminHandler) Reports(c *gin.Context) {
+	if _, ok := h.requireAdmin(c); !ok {
+		return
+	}
+
+	cond, ok := reportStatuses[c.DefaultQuery("status", "open")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, errUnknownReportStatus.Error())})
+		return
+	}
+	limit, offset, err := parsePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+
+	rows, err := h.db.Query(
+		`SELECT r.id, r.message_id, rcv.github_login, a.github_login, rep.github_login, r.reason, r.content,
+		        m.hidden_at IS NOT NULL, r.created_at, r.resolved_at, r.resolution
+		 FROM reports r
+		 JOIN users rcv ON rcv.id = r.receiver_id
+		 JOIN users a ON a.id = r.author_id
+		 JOIN users rep ON rep.id = r.reporter_id
+		 LEFT JOIN messages m ON m.id = r.message_id
+		 WHERE `+cond+`
+		 ORDER BY r.created_at DESC, r.id DESC
+		 LIMIT $1 OFFSET $2`,
+		limit, offset,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get reports")})
+		return
+	}
+	defer rows.Close()
+
+	reports := make([]model.Report, 0)
+	for rows.Next() {
+		var r model.Report
+		var hidden sql.NullBool
+		if err := rows.Scan(&r.ID, &r.MessageID, &r.Receiver, &r.Author, &r.Reporter, &r.Reason, &r.Content,
+			&hidden, &r.CreatedAt, &r.ResolvedAt, &r.Resolution); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get reports")})
+			return
+		}
+		r.Hidden = hidden.Bool
+		reports = append(reports, r)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get reports")})
+		return
+	}
+
+	c.JSON(http.StatusOK, reports)
+}
+
+// Resolve closes an open report. The "hide" action also hides the reported
+// message and closes every other open report on it; "dismiss" only closes
+// this report.
+func (h *AdminHandler) Resolve(c *gin.Context) {
+	reportID, err := strconv.ParseInt(c.Param("reportID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "Invalid report ID")})
+		return
+	}
+	adminID, ok := h.requireAdmin(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Action string `json:"action"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+	if req.Action != "hide" && req.Action != "dismiss" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "Unknown action")})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to resolve report")})
+		return
+	}
+	defer tx.Rollback()
+
+	var messageID sql.NullInt64
+	var receiverID int64
+	err = tx.QueryRow(
+		"SELECT message_id, receiver_id FROM reports WHERE id = $1 AND resolved_at IS NULL FOR UPDATE",
+		reportID,
+	).Scan(&messageID, &receiverID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "Report not found")})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to resolve report")})
+		return
+	}
+
+	resolve := "UPDATE reports SET resolved_at = NOW(), resolved_by = $2, resolution = $3 WHERE id = $1"
+	args := []any{reportID, adminID, reportDismissed}
+	if req.Action == "hide" {
+		if !messageID.Valid {
+			c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "Message not found")})
+			return
+		}
+		if _, err := tx.Exec(
+			"UPDATE messages SET hidden_at = NOW(), hidden_by = $2 WHERE id = $1 AND hidden_at IS NULL",
+			messageID.Int64, adminID,
+		); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to resolve report")})
+			return
+		}
+		resolve = "UPDATE reports SET resolved_at = NOW(), resolved_by = $2, resolution = $3 WHERE message_id = $1 AND resolved_at IS NULL"
+		args = []any{messageID.Int64, adminID, reportHidden}
+	}
+	if _, err := tx.Exec(resolve, args...); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to resolve report")})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to resolve report")})
+		return
+	}
+	if req.Action == "hide" {
+		h.cache.Invalidate(receiverID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Report resolved"})
+}
+
+// Hide hides a message site-wide. Only its author still sees it.
+func (h *AdminHandler) Hide(c *gin.Context) {
+	h.setHidden(c, true)
+}
+
+func (h *AdminHandler) Unhide(c *gin.Context) {
+	h.setHidden(c, false)
+}
+
+func (h *AdminHandler) setHidden(c *gin.Context, hidden bool) {
+	messageID, ok := parseMessageID(c)
+	if !ok {
+		return
+	}
+	adminID, ok := h.requireAdmin(c)
+	if !ok {
+		return
+	}
+
+	// Hiding a hidden message keeps who hid it first and when.
+	query := `UPDATE messages
+		SET hidden_at = COALESCE(hidden_at, NOW()), hidden_by = CASE WHEN hidden_at IS NULL THEN $2 ELSE hidden_by END
+		WHERE id = $1 RETURNING receiver_id`
+	args := []any{messageID, adminID}
+	failMsg, doneMsg := "Failed to hide message", "Message hidden"
+	if !hidden {
+		query = "UPDATE messages SET hidden_at = NULL, hidden_by = NULL WHERE id = $1 RETURNING receiver_id"
+		args = []any{messageID}
+		failMsg, doneMsg = "Failed to unhide message", "Message unhidden"
+	}
+
+	var receiverID int64
+	err := h.db.QueryRow(query, args...).Scan(&receiverID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "Message not found")})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, failMsg)})
+		return
+	}
+	h.cache.Invalidate(receiverID)
+
+	c.JSON(http.StatusOK, gin.H{"message": doneMsg})
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	avatarFetchSize    = 48
+	avatarMaxBytes     = 256 << 10
+	avatarFailureTTL   = 5 * time.Minute
+	avatarFetchTimeout = 5 * time.Second
+)
+
+type avatarImage struct {
+	data    []byte
+	mime    string
+	decoded image.Image
+}
+
+type avatarEntry struct {
+	img       *avatarImage
+	expiresAt time.Time
+}
+
+// avatarCache keeps author avatars in memory so they can be embedded as data
+// URIs. Lookups never block on the network: a miss schedules a background
+// fetch and the caller draws initials until the avatar arrives.
+type avatarCache struct {
+	mu      sync.Mutex
+	entries map[int64]*avatarEntry
+	pending map[int64]bool
+	baseURL string
+	ttl     time.Duration
+	client  *http.Client
+}
+
+func newAvatarCache(baseURL string, ttl time.Duration) *avatarCache {
+	ac := &avatarCache{
+		entries: make(map[int64]*avatarEntry),
+		pending: make(map[int64]bool),
+		baseURL: strings.TrimRight(baseURL, "/"),
+		ttl:     ttl,
+		client:  &http.Client{Timeout: avatarFetchTimeout},
+	}
+	go ac.cleanup()
+	return ac
+}
+
+func (ac *avatarCache) get(githubID int64) *avatarImage {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	entry, ok := ac.entries[githubID]
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.img
+	}
+
+	if !ac.pending[githubID] {
+		ac.pending[githubID] = true
+		go ac.refresh(githubID)
+	}
+
+	if ok {
+		return entry.img
+	}
+	return nil
+}
+
+func (ac *avatarCache) refresh(githubID int64) {
+	img, _ := ac.fetch(githubID)
+
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	delete(ac.pending, githubID)
+
+	if img != nil {
+		ac.entries[githubID] = &avatarEntry{img: img, expiresAt: time.Now().Add(ac.ttl)}
+		return
+	}
+
+	// Keep serving a stale avatar rather than replacing it with a failure.
+	if prev, ok := ac.entries[githubID]; ok {
+		prev.expiresAt = time.Now().Add(avatarFailureTTL)
+		return
+	}
+	ac.entries[githubID] = &avatarEntry{expiresAt: time.Now().Add(avatarFailureTTL)}
+}
+
+func (ac *avatarCache) fetch(githubID int64) (*avatarImage, error) {
+	resp, err := ac.client.Get(fmt.Sprintf("%s/u/%d?s=%d", ac.baseURL, githubID, avatarFetchSize))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("avatar %d: unexpected status %d", githubID, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, avatarMaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > avatarMaxBytes {
+		return nil, fmt.Errorf("avatar %d: too large", githubID)
+	}
+
+	decoded, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return &avatarImage{data: data, mime: "image/" + format, decoded: decoded}, nil
+}
+
+func (ac *avatarCache) cleanup() {
+	ticker := time.NewTicker(10 * time.Minute)
+	for range ticker.C {
+		ac.mu.Lock()
+		for id, entry := range ac.entries {
+			if time.Since(entry.expiresAt) > ac.ttl {
+				delete(ac.entries, id)
+			}
+		}
+		ac.mu.Unlock()
+	}
+}
+
+func initials(login string) string {
+	for _, r := range login {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return string(unicode.ToUpper(r))
+		}
+	}
+	return "?"
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/in-jun/github-profile-guestbook/internal/model"
+)
+
+// requireNotBlocked writes a 403 response unless userID may post and react
+// on receiverID's guestbook. failMsg is reported if the check fails.
+func requireNotBlocked(c *gin.Context, db *sql.DB, receiverID, userID int64, failMsg string) bool {
+	var blocked bool
+	err := db.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM blocked_authors WHERE receiver_id = $1 AND author_id = $2)",
+		receiverID, userID,
+	).Scan(&blocked)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, failMsg)})
+		return false
+	}
+	if blocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": tr(c, "You have been blocked from this guestbook")})
+		return false
+	}
+	return true
+}
+
+// BlockHandler manages the authors an owner has blocked from their own
+// guestbook.
+type BlockHandler struct {
+	db    *sql.DB
+	cache *RenderCache
+}
+
+func NewBlockHandler(db *sql.DB, cache *RenderCache) *BlockHandler {
+	return &BlockHandler{db: db, cache: cache}
+}
+
+func (h *BlockHandler) List(c *gin.Context) {
+	userID, ok := requireAuth(c)
+	if !ok {
+		return
+	}
+
+	rows, err := h.db.Query(
+		`SELECT u.github_login, b.hide_messages, b.created_at
+		 FROM blocked_authors b
+		 JOIN users u ON u.id = b.author_id
+		 WHERE b.receiver_id = $1
+		 ORDER BY b.created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get blocked authors")})
+		return
+	}
+	defer rows.Close()
+
+	blocked := make([]model.BlockedAuthor, 0)
+	for rows.Next() {
+		var ba model.BlockedAuthor
+		if err := rows.Scan(&ba.Author, &ba.HideMessages, &ba.CreatedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get blocked authors")})
+			return
+		}
+		blocked = append(blocked, ba)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get blocked authors")})
+		return
+	}
+
+	c.JSON(http.StatusOK, blocked)
+}
+
+// Block blocks the author from signing or reacting on the caller's guestbook.
+// With hide_messages their existing message is hidden too, until they are
+// unblocked. Blocking an author again updates hide_messages.
+func (h *BlockHandler) Block(c *gin.Context) {
+	userID, ok := requireAuth(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		HideMessages bool `json:"hide_messages"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+
+	var authorID int64
+	err := h.db.QueryRow("SELECT id FROM users WHERE github_login = $1", c.Param("username")).Scan(&authorID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "GitHub user not found")})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to block author")})
+		return
+	}
+	if authorID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "You can't block yourself")})
+		return
+	}
+
+	_, err = h.db.Exec(
+		`INSERT INTO blocked_authors (receiver_id, author_id, hide_messages)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (receiver_id, author_id) DO UPDATE SET hide_messages = EXCLUDED.hide_messages`,
+		userID, authorID, req.HideMessages,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to block author")})
+		return
+	}
+	h.cache.Invalidate(userID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Author blocked"})
+}
+
+func (h *BlockHandler) Unblock(c *gin.Context) {
+	userID, ok := requireAuth(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.db.Exec(
+		`DELETE FROM blocked_authors
+		 WHERE receiver_id = $1 AND author_id = (SELECT id FROM users WHERE github_login = $2)`,
+		userID, c.Param("username"),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to unblock author")})
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "Author is not blocked")})
+		return
+	}
+	h.cache.Invalidate(userID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Author unblocked"})
+}
@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/in-jun/github-profile-guestbook/internal/i18n"
+)
+
+type colorRole int
+
+const (
+	colorNone colorRole = iota
+	colorBg
+	colorText
+	colorBorder
+	colorGray
+	colorAccent
+)
+
+func (r colorRole) cssValue() string {
+	switch r {
+	case colorBg:
+		return "var(--bg-color)"
+	case colorText:
+		return "var(--text-color)"
+	case colorBorder:
+		return "var(--border-color)"
+	case colorGray:
+		return "var(--gray-color)"
+	case colorAccent:
+		return "var(--accent-color)"
+	default:
+		return "none"
+	}
+}
+
+type textAnchor int
+
+const (
+	anchorStart textAnchor = iota
+	anchorMiddle
+	anchorEnd
+)
+
+type textStyle struct {
+	size          int
+	weight        int
+	color         colorRole
+	anchor        textAnchor
+	middle        bool // y is the vertical center instead of the baseline
+	letterSpacing float64
+	italic        bool
+	mono          bool
+}
+
+const monoFontFamily = `ui-monospace, SFMono-Regular, Menlo, Consolas, monospace`
+
+// textRun is part of a line of formatted text. Runs with an href are links
+// and are drawn in the accent color.
+type textRun struct {
+	text   string
+	bold   bool
+	italic bool
+	mono   bool
+	href   string
+}
+
+// style returns the style the run is drawn with in a line drawn with base.
+func (r textRun) style(base textStyle) textStyle {
+	if r.bold {
+		base.weight = 700
+	}
+	base.italic = base.italic || r.italic
+	base.mono = base.mono || r.mono
+	if r.href != "" {
+		base.color = colorAccent
+	}
+	return base
+}
+
+// canvas is the set of drawing primitives the guestbook layouts are written
+// against, so the same layout code can produce SVG markup or a raster image.
+type canvas interface {
+	begin(width, height int)
+	rect(x, y, w, h int, fill, stroke colorRole)
+	circle(cx, cy, r int, fill colorRole)
+	line(x1, y1, x2, y2 int, stroke colorRole)
+	avatar(x, y, size int, img *avatarImage)
+	text(x, y int, s string, style textStyle)
+	textLines(x, y, lineHeight int, lines [][]textRun, style textStyle)
+
+	// slides starts a sequence of count slides that are shown in turn for
+	// interval each. Drawing after slide(i) belongs to slide i until the next
+	// slide call or endSlides. slide reports whether the canvas draws slide i
+	// at all; canvases that cannot animate only draw the first.
+	slides(count int, interval time.Duration)
+	slide(i int) bool
+	endSlides()
+}
+
+type svgCanvas struct {
+	builder   strings.Builder
+	theme     theme
+	lang      string
+	clipIDs   int
+	animated  bool
+	slideOpen bool
+}
+
+func newSVGCanvas(th theme, lang string) *svgCanvas {
+	return &svgCanvas{theme: th, lang: lang}
+}
+
+func (cv *svgCanvas) begin(width, height int) {
+	// xml:lang lets viewers pick the right CJK glyph variants.
+	cv.builder.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" xml:lang="%s" width="%d" height="%d">`, cv.lang, width, height))
+	cv.builder.WriteString(cv.theme.style(i18n.FontFamily(cv.lang)))
+}
+
+func (cv *svgCanvas) rect(x, y, w, h int, fill, stroke colorRole) {
+	if stroke == colorNone {
+		cv.builder.WriteString(fmt.Sprintf(`<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`,
+			x, y, w, h, fill.cssValue()))
+		return
+	}
+	cv.builder.WriteString(fmt.Sprintf(`<rect x="%d" y="%d" width="%d" height="%d" fill="%s" stroke="%s" stroke-width="1"/>`,
+		x, y, w, h, fill.cssValue(), stroke.cssValue()))
+}
+
+func (cv *svgCanvas) circle(cx, cy, r int, fill colorRole) {
+	cv.builder.WriteString(fmt.Sprintf(`<circle cx="%d" cy="%d" r="%d" fill="%s"/>`, cx, cy, r, fill.cssValue()))
+}
+
+func (cv *svgCanvas) avatar(x, y, size int, img *avatarImage) {
+	cv.clipIDs++
+	id := fmt.Sprintf("avatar-%d", cv.clipIDs)
+	cv.builder.WriteString(fmt.Sprintf(`<clipPath id="%s"><circle cx="%d" cy="%d" r="%d"/></clipPath>`,
+		id, x+size/2, y+size/2, size/2))
+	cv.builder.WriteString(fmt.Sprintf(`<image x="%d" y="%d" width="%d" height="%d" clip-path="url(#%s)" href="data:%s;base64,%s"/>`,
+		x, y, size, size, id, img.mime, base64.StdEncoding.EncodeToString(img.data)))
+}
+
+func (cv *svgCanvas) line(x1, y1, x2, y2 int, stroke colorRole) {
+	cv.builder.WriteString(fmt.Sprintf(`<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="1"/>`,
+		x1, y1, x2, y2, stroke.cssValue()))
+}
+
+func (cv *svgCanvas) openText(x, y int, style textStyle) {
+	cv.builder.WriteString(fmt.Sprintf(`<text x="%d" y="%d" font-size="%d"`, x, y, style.size))
+	if style.weight != 0 {
+		cv.builder.WriteString(fmt.Sprintf(` font-weight="%d"`, style.weight))
+	}
+	cv.builder.WriteString(fmt.Sprintf(` fill="%s"`, style.color.cssValue()))
+	if style.letterSpacing != 0 {
+		cv.builder.WriteString(fmt.Sprintf(` letter-spacing="%g"`, style.letterSpacing))
+	}
+	if style.italic {
+		cv.builder.WriteString(` font-style="italic"`)
+	}
+	if style.mono {
+		cv.builder.WriteString(` font-family="` + monoFontFamily + `"`)
+	}
+	switch style.anchor {
+	case anchorMiddle:
+		cv.builder.WriteString(` text-anchor="middle"`)
+	case anchorEnd:
+		cv.builder.WriteString(` text-anchor="end"`)
+	}
+	if style.middle {
+		cv.builder.WriteString(` dominant-baseline="middle"`)
+	}
+	cv.builder.WriteString(`>`)
+}
+
+func (cv *svgCanvas) text(x, y int, s string, style textStyle) {
+	cv.openText(x, y, style)
+	cv.builder.WriteString(template.HTMLEscapeString(s))
+	cv.builder.WriteString(`</text>`)
+}
+
+func (cv *svgCanvas) textLines(x, y, lineHeight int, lines [][]textRun, style textStyle) {
+	cv.openText(x, y, style)
+	for i, line := range lines {
+		dy := 0
+		if i > 0 {
+			dy = lineHeight
+		}
+		cv.builder.WriteString(fmt.Sprintf(`<tspan x="%d" dy="%d">`, x, dy))
+		for _, run := range line {
+			cv.writeRun(run, style)
+		}
+		cv.builder.WriteString(`</tspan>`)
+	}
+	cv.builder.WriteString(`</text>`)
+}
+
+// writeRun writes run inside a text element drawn with base, styling only
+// what differs from base.
+func (cv *svgCanvas) writeRun(run textRun, base textStyle) {
+	style := run.style(base)
+	var attrs strings.Builder
+	if style.weight != base.weight {
+		attrs.WriteString(fmt.Sprintf(` font-weight="%d"`, style.weight))
+	}
+	if style.italic != base.italic {
+		attrs.WriteString(` font-style="italic"`)
+	}
+	if style.mono != base.mono {
+		attrs.WriteString(` font-family="` + monoFontFamily + `"`)
+	}
+	if style.color != base.color {
+		attrs.WriteString(fmt.Sprintf(` fill="%s"`, style.color.cssValue()))
+	}
+
+	if run.href != "" {
+		cv.builder.WriteString(`<a href="` + template.HTMLEscapeString(run.href) + `">`)
+	}
+	if attrs.Len() > 0 {
+		cv.builder.WriteString(`<tspan` + attrs.String() + `>`)
+	}
+	cv.builder.WriteString(template.HTMLEscapeString(run.text))
+	if attrs.Len() > 0 {
+		cv.builder.WriteString(`</tspan>`)
+	}
+	if run.href != "" {
+		cv.builder.WriteString(`</a>`)
+	}
+}
+
+// slides cross-fades the slides with CSS keyframes. The first slide stays
+// visible where animations don't run, and is the only one shown to users who
+// prefer reduced motion.
+func (cv *svgCanvas) slides(count int, interval time.Duration) {
+	cv.animated = count > 1
+	if !cv.animated {
+		return
+	}
+
+	total := interval * time.Duration(count)
+	fade := min(500*time.Millisecond, interval/4)
+	percent := func(d time.Duration) string {
+		return strconv.FormatFloat(100*float64(d)/float64(total), 'f', 2, 64) + "%"
+	}
+
+	cv.builder.WriteString("<style>\n")
+	cv.builder.WriteString(fmt.Sprintf("\t\t.slide { opacity: 0; animation: carousel %gs linear infinite; }\n", total.Seconds()))
+	cv.builder.WriteString("\t\t.slide-0 { opacity: 1; }\n")
+	for i := 1; i < count; i++ {
+		cv.builder.WriteString(fmt.Sprintf("\t\t.slide-%d { animation-delay: %gs; }\n", i, (interval * time.Duration(i)).Seconds()))
+	}
+	cv.builder.WriteString(fmt.Sprintf("\t\t@keyframes carousel { 0%% { opacity: 0; } %s { opacity: 1; } %s { opacity: 1; } %s { opacity: 0; } 100%% { opacity: 0; } }\n",
+		percent(fade), percent(interval-fade), percent(interval)))
+	cv.builder.WriteString("\t\t@media (prefers-reduced-motion: reduce) {\n")
+	cv.builder.WriteString("\t\t\t.slide { animation: none; }\n")
+	cv.builder.WriteString("\t\t}\n")
+	cv.builder.WriteString("\t</style>")
+}
+
+func (cv *svgCanvas) slide(i int) bool {
+	if !cv.animated {
+		return true
+	}
+	if cv.slideOpen {
+		cv.builder.WriteString(`</g>`)
+	}
+	cv.builder.WriteString(fmt.Sprintf(`<g class="slide slide-%d">`, i))
+	cv.slideOpen = true
+	return true
+}
+
+func (cv *svgCanvas) endSlides() {
+	if cv.slideOpen {
+		cv.builder.WriteString(`</g>`)
+	}
+	cv.animated, cv.slideOpen = false, false
+}
+
+func (cv *svgCanvas) String() string {
+	return cv.builder.String() + "</svg>"
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/in-jun/github-profile-guestbook/internal/i18n"
+	"github.com/in-jun/github-profile-guestbook/internal/model"
+)
+
+var errUnsupportedLanguage = errors.New("Unsupported language")
+
+// tr translates an API message into the caller's language.
+func tr(c *gin.Context, msg string) string {
+	return i18n.T(i18n.FromRequest(c.Request), msg)
+}
+
+// resolveLanguage picks the language a guestbook is drawn in: the lang query
+// parameter, then the owner's saved preference.
+func resolveLanguage(c *gin.Context, settings model.GuestbookSettings) (string, error) {
+	if q := c.Query("lang"); q != "" {
+		lang, ok := i18n.Match(q)
+		if !ok {
+			return "", errUnsupportedLanguage
+		}
+		return lang, nil
+	}
+	if settings.Language != nil {
+		return *settings.Language, nil
+	}
+	return i18n.Default, nil
+}
+
+// relativeTime describes how long before now t was, such as "3 days ago".
+func relativeTime(lang string, t, now time.Time) string {
+	const (
+		day   = 24 * time.Hour
+		month = 30 * day
+		year  = 365 * day
+	)
+
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return i18n.T(lang, "just now")
+	case d < time.Hour:
+		return plural(lang, int(d/time.Minute), "1 minute ago", "%d minutes ago")
+	case d < day:
+		return plural(lang, int(d/time.Hour), "1 hour ago", "%d hours ago")
+	case d < month:
+		return plural(lang, int(d/day), "1 day ago", "%d days ago")
+	case d < year:
+		// Twelve 30-day months are still short of a year.
+		return plural(lang, min(int(d/month), 11), "1 month ago", "%d months ago")
+	default:
+		return plural(lang, int(d/year), "1 year ago", "%d years ago")
+	}
+}
+
+func plural(lang string, n int, one, other string) string {
+	if n == 1 {
+		return i18n.T(lang, one)
+	}
+	return i18n.Tf(lang, other, n)
+}
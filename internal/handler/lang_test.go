@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRelativeTime(t *testing.T) {
+	const day = 24 * time.Hour
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{59 * time.Second, "just now"},
+		{time.Minute, "1 minute ago"},
+		{59 * time.Minute, "59 minutes ago"},
+		{23 * time.Hour, "23 hours ago"},
+		{29 * day, "29 days ago"},
+		{30 * day, "1 month ago"},
+		{360 * day, "11 months ago"},
+		{364 * day, "11 months ago"},
+		{365 * day, "1 year ago"},
+		{730 * day, "2 years ago"},
+	}
+	for _, tt := range tests {
+		if got := relativeTime("en", now.Add(-tt.ago), now); got != tt.want {
+			t.Errorf("relativeTime(%v ago) = %q, want %q", tt.ago, got, tt.want)
+		}
+	}
+}
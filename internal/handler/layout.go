@@ -0,0 +1,306 @@
+package handler
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/in-jun/github-profile-guestbook/internal/i18n"
+	"github.com/in-jun/github-profile-guestbook/internal/model"
+)
+
+type messagePage struct {
+	limit       int
+	offset      int
+	starredOnly bool
+}
+
+// cardLayout is implemented by every way of drawing a guestbook. page tells
+// loadMessages which messages to fetch, draw renders the loaded card.
+type cardLayout interface {
+	page(opts renderOptions) messagePage
+	draw(cv canvas, card *guestbookCard)
+}
+
+var cardLayouts = map[string]cardLayout{
+	"full":    fullLayout{},
+	"badge":   badgeLayout{},
+	"compact": compactLayout{},
+}
+
+const (
+	cardPadding               = 24
+	cardHeaderFontSize        = 24
+	cardHeaderBaseline        = 18
+	cardHeaderBottomGap       = 16
+	cardSectionTitleFontSize  = 14
+	cardSectionTitleBaseline  = 11
+	cardSectionTitleTopGap    = 24
+	cardSectionTitleBottomGap = 24
+	cardTitleDescent          = 3
+
+	// cardHeaderHeight is the height drawCardHeader occupies.
+	cardHeaderHeight = cardPadding + cardHeaderBaseline + cardHeaderBottomGap + cardSectionTitleTopGap +
+		cardSectionTitleBaseline + cardTitleDescent + cardSectionTitleBottomGap
+)
+
+// drawCardHeader draws the username, divider and section title shared by the
+// card layouts and returns the y coordinate where content starts.
+func drawCardHeader(cv canvas, width int, userName, title string) int {
+	headerTextY := cardPadding + cardHeaderBaseline
+	headerLineY := headerTextY + cardHeaderBottomGap
+	sectionTitleY := headerLineY + cardSectionTitleTopGap + cardSectionTitleBaseline
+
+	cv.text(cardPadding, headerTextY, userName, textStyle{size: cardHeaderFontSize, weight: 700, color: colorText})
+	cv.line(cardPadding, headerLineY, width-cardPadding, headerLineY, colorBorder)
+	cv.text(cardPadding, sectionTitleY, title, textStyle{size: cardSectionTitleFontSize, weight: 700, color: colorGray, letterSpacing: 0.5})
+
+	return sectionTitleY + cardTitleDescent + cardSectionTitleBottomGap
+}
+
+func drawChip(cv canvas, x, centerY, width, height int, label string, style textStyle) {
+	cv.rect(x, centerY-height/2, width, height, colorNone, colorBorder)
+	cv.text(x+width/2, centerY, label, style)
+}
+
+// drawAuthor draws the author's avatar, or their initials while the avatar
+// is not cached, followed by their login on the given baseline.
+func drawAuthor(cv canvas, x, baseline int, message model.SvgMessageModel, img *avatarImage) {
+	const (
+		avatarSize = 20
+		avatarGap  = 8
+	)
+
+	top := baseline - 15
+	if img != nil {
+		cv.avatar(x, top, avatarSize, img)
+	} else {
+		cv.circle(x+avatarSize/2, top+avatarSize/2, avatarSize/2, colorBorder)
+		cv.text(x+avatarSize/2, top+avatarSize/2, initials(message.Author),
+			textStyle{size: 10, weight: 700, color: colorText, anchor: anchorMiddle, middle: true})
+	}
+	cv.text(x+avatarSize+avatarGap, baseline, message.Author, textStyle{size: 14, weight: 700, color: colorText})
+}
+
+type fullLayout struct{}
+
+func (fullLayout) page(opts renderOptions) messagePage {
+	return messagePage{limit: opts.limit, offset: opts.offset}
+}
+
+func (fullLayout) draw(cv canvas, card *guestbookCard) {
+	if !card.claimed {
+		generateLoginPrompt(cv, card.username, card.lang)
+		return
+	}
+	generateMessageBox(cv, card)
+}
+
+// badgeLayout is a shields.io style summary: "guestbook | 42 messages | ★ 3".
+type badgeLayout struct{}
+
+func (badgeLayout) page(renderOptions) messagePage {
+	return messagePage{}
+}
+
+func (badgeLayout) draw(cv canvas, card *guestbookCard) {
+	const (
+		height      = 20
+		fontSize    = 11
+		textPadding = 6
+	)
+
+	type segment struct {
+		label      string
+		fill, text colorRole
+	}
+
+	segments := []segment{{label: i18n.T(card.lang, "guestbook"), fill: colorGray, text: colorBg}}
+	switch {
+	case !card.claimed:
+		segments = append(segments, segment{label: i18n.T(card.lang, "unclaimed"), fill: colorBorder, text: colorText})
+	case card.total == 1:
+		segments = append(segments, segment{label: i18n.T(card.lang, "1 message"), fill: colorAccent, text: colorBg})
+	default:
+		segments = append(segments, segment{label: i18n.Tf(card.lang, "%d messages", card.total), fill: colorAccent, text: colorBg})
+	}
+	if card.starred > 0 {
+		segments = append(segments, segment{label: fmt.Sprintf("★ %d", card.starred), fill: colorBorder, text: colorText})
+	}
+
+	widths := make([]int, len(segments))
+	total := 0
+	for i, seg := range segments {
+		widths[i] = int(math.Ceil(measureText(seg.label, fontSize))) + textPadding*2
+		total += widths[i]
+	}
+
+	cv.begin(total, height)
+	x := 0
+	for i, seg := range segments {
+		cv.rect(x, 0, widths[i], height, seg.fill, colorNone)
+		cv.text(x+widths[i]/2, height/2, seg.label, textStyle{size: fontSize, weight: 500, color: seg.text, anchor: anchorMiddle, middle: true})
+		x += widths[i]
+	}
+}
+
+// compactLayout is a narrow card listing only the owner's starred messages.
+type compactLayout struct{}
+
+const defaultCompactLimit = 3
+
+func (compactLayout) page(opts renderOptions) messagePage {
+	limit := defaultCompactLimit
+	if opts.limitSet {
+		limit = opts.limit
+	}
+	return messagePage{limit: limit, offset: opts.offset, starredOnly: true}
+}
+
+func (compactLayout) draw(cv canvas, card *guestbookCard) {
+	const (
+		width             = 400
+		padding           = cardPadding
+		boxPadding        = 12
+		boxGap            = 12
+		authorHeight      = 21
+		contentFontSize   = 13
+		contentLineHeight = 19
+		maxContentLines   = 3
+		emptyBoxHeight    = 56
+		footerHeight      = 20
+		bottomPadding     = 24
+	)
+
+	if !card.claimed {
+		generateLoginPrompt(cv, card.username, card.lang)
+		return
+	}
+
+	textX := padding + boxPadding
+	textWidth := width - padding*2 - boxPadding*2
+
+	lines := make([][][]textRun, len(card.messages))
+	heights := make([]int, len(card.messages))
+	contentHeight := 0
+	for i, message := range card.messages {
+		lines[i] = wrapMarkup(message.Markup, contentFontSize, float64(textWidth), maxContentLines)
+		heights[i] = boxPadding*2 + authorHeight + len(lines[i])*contentLineHeight
+		contentHeight += heights[i] + boxGap
+	}
+	if len(card.messages) == 0 {
+		contentHeight = emptyBoxHeight + boxGap
+	}
+
+	totalHeight := cardHeaderHeight + contentHeight - boxGap + bottomPadding
+	if card.remaining > 0 {
+		totalHeight += footerHeight
+	}
+
+	cv.begin(width, totalHeight)
+	cv.rect(0, 0, width, totalHeight, colorBg, colorNone)
+	y := drawCardHeader(cv, width, card.username, i18n.T(card.lang, "STARRED"))
+
+	if len(card.messages) == 0 {
+		cv.rect(padding, y, width-padding*2, emptyBoxHeight, colorNone, colorBorder)
+		cv.text(width/2, y+emptyBoxHeight/2, i18n.T(card.lang, "No starred messages yet"), textStyle{size: 13, color: colorGray, anchor: anchorMiddle, middle: true})
+		y += emptyBoxHeight + boxGap
+	}
+
+	for i, message := range card.messages {
+		cv.rect(padding, y, width-padding*2, heights[i], colorNone, colorBorder)
+		drawAuthor(cv, textX, y+boxPadding+15, message, card.avatars[message.AuthorGitHubID])
+		cv.text(width-padding-boxPadding-6, y+boxPadding+10, "★", textStyle{size: 12, color: colorAccent, anchor: anchorMiddle, middle: true})
+		if card.timestamps {
+			cv.text(width-padding-boxPadding-18, y+boxPadding+15, relativeTime(card.lang, message.CreatedAt, card.now),
+				textStyle{size: 12, color: colorGray, anchor: anchorEnd})
+		}
+		cv.textLines(textX, y+boxPadding+authorHeight+14, contentLineHeight, lines[i], textStyle{size: contentFontSize, color: colorText})
+		y += heights[i] + boxGap
+	}
+
+	if card.remaining > 0 {
+		cv.text(width/2, y-boxGap+footerHeight/2+4, i18n.Tf(card.lang, "+ %d more — view all", card.remaining),
+			textStyle{size: 12, color: colorGray, anchor: anchorMiddle, middle: true})
+	}
+}
+
+// carouselLayout is a fixed-height card that cycles through the top messages
+// one at a time.
+type carouselLayout struct{}
+
+const defaultCarouselLimit = 5
+
+func (carouselLayout) page(opts renderOptions) messagePage {
+	limit := defaultCarouselLimit
+	if opts.limitSet {
+		limit = opts.limit
+	}
+	return messagePage{limit: limit, offset: opts.offset}
+}
+
+func (carouselLayout) draw(cv canvas, card *guestbookCard) {
+	const (
+		width         = 800
+		padding       = cardPadding
+		boxWidth      = width - padding*2
+		dotRadius     = 3
+		dotGap        = 14
+		dotsHeight    = 28
+		bottomPadding = 24
+	)
+
+	if !card.claimed {
+		generateLoginPrompt(cv, card.username, card.lang)
+		return
+	}
+
+	// Every slide gets the height of the tallest message so the card doesn't
+	// jump between slides.
+	lines := make([][][]textRun, len(card.messages))
+	boxHeight := emptyMessagesHeight
+	repliesHeight := 0
+	for i, message := range card.messages {
+		var height int
+		lines[i], height = wrapMessage(message, boxWidth)
+		if i == 0 || height > boxHeight {
+			boxHeight = height
+		}
+		repliesHeight = max(repliesHeight, replyHeight(message))
+	}
+
+	totalHeight := cardHeaderHeight + boxHeight + repliesHeight + bottomPadding
+	if len(card.messages) > 1 {
+		totalHeight += dotsHeight
+	}
+
+	cv.begin(width, totalHeight)
+	cv.rect(0, 0, width, totalHeight, colorBg, colorNone)
+	y := drawCardHeader(cv, width, card.username, i18n.T(card.lang, "GUESTBOOK"))
+
+	if len(card.messages) == 0 {
+		drawEmptyMessages(cv, card, padding, y, boxWidth)
+		return
+	}
+
+	dotsY := y + boxHeight + repliesHeight + dotsHeight/2 + 4
+	dotsX := width/2 - (len(card.messages)-1)*dotGap/2
+
+	cv.slides(len(card.messages), card.interval)
+	for i, message := range card.messages {
+		if !cv.slide(i) {
+			continue
+		}
+		drawMessageBox(cv, card, message, padding, y, boxWidth, boxHeight, lines[i])
+		drawReply(cv, card, message, padding, y+boxHeight, boxWidth)
+		if len(card.messages) > 1 {
+			for j := range card.messages {
+				dot := colorBorder
+				if j == i {
+					dot = colorAccent
+				}
+				cv.circle(dotsX+j*dotGap, dotsY, dotRadius, dot)
+			}
+		}
+	}
+	cv.endSlides()
+}
@@ -2,207 +2,257 @@ package handler
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/in-jun/github-profile-guestbook/internal/model"
 )
 
 type LikeHandler struct {
-	db *sql.DB
+	db    *sql.DB
+	cache *RenderCache
 }
 
-func NewLikeHandler(db *sql.DB) *LikeHandler {
-	return &LikeHandler{db: db}
+func NewLikeHandler(db *sql.DB, cache *RenderCache) *LikeHandler {
+	return &LikeHandler{db: db, cache: cache}
 }
 
-func (h *LikeHandler) parseMessageID(c *gin.Context) (int64, bool) {
+func parseMessageID(c *gin.Context) (int64, bool) {
 	messageID := c.Param("messageID")
 	id, err := strconv.ParseInt(messageID, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Message ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "Invalid Message ID")})
 		return 0, false
 	}
 	return id, true
 }
 
-func (h *LikeHandler) requireAuth(c *gin.Context) (int64, bool) {
+func requireAuth(c *gin.Context) (int64, bool) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "Unauthorized")})
 		return 0, false
 	}
 	return userID.(int64), true
 }
 
-func (h *LikeHandler) Like(c *gin.Context) {
-	messageID, ok := h.parseMessageID(c)
-	if !ok {
-		return
-	}
-	userID, ok := h.requireAuth(c)
-	if !ok {
-		return
-	}
+// Refusals of the single-purpose like and dislike endpoints, which only
+// change a vote from the state they expect.
+var (
+	errAlreadyLiked    = errors.New("You have already liked this message")
+	errAlreadyDisliked = errors.New("You have already disliked this message")
+	errNotLiked        = errors.New("Message not liked")
+	errNotDisliked     = errors.New("Message not disliked")
+)
 
-	var authorID int64
-	err := h.db.QueryRow("SELECT author_id FROM messages WHERE id = $1", messageID).Scan(&authorID)
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
-		return
+// voteEmoji maps a vote to the reaction it is stored as.
+var voteEmoji = map[int]string{1: reactionLike, -1: reactionDislike}
+
+// emojiVote returns the vote a reaction stands for, or 0 for reactions
+// other than likes and dislikes.
+func emojiVote(emoji string) int {
+	for v, e := range voteEmoji {
+		if e == emoji {
+			return v
+		}
 	}
+	return 0
+}
+
+// applyVote replaces the user's like or dislike on a message with value: 1
+// likes it, -1 dislikes it and 0 clears the vote. It runs in one
+// transaction holding a lock on the message, so concurrent votes can't leave
+// both a like and a dislike behind. check, if set, sees the previous vote
+// and may refuse the change. It returns the guestbook of the message and the
+// counts after the change.
+func applyVote(db *sql.DB, messageID, userID int64, value int, check func(prev int) error) (int64, model.VoteResponse, error) {
+	res := model.VoteResponse{Value: value}
+
+	tx, err := db.Begin()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to like message"})
-		return
+		return 0, res, err
 	}
+	defer tx.Rollback()
 
-	if authorID == userID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "You can't like your own message"})
-		return
+	var receiverID int64
+	if err := tx.QueryRow("SELECT receiver_id FROM messages WHERE id = $1 FOR NO KEY UPDATE", messageID).Scan(&receiverID); err != nil {
+		return 0, res, err
 	}
 
-	var existingType *int16
-	err = h.db.QueryRow("SELECT type FROM reactions WHERE message_id = $1 AND user_id = $2", messageID, userID).Scan(&existingType)
+	var prevEmoji string
+	err = tx.QueryRow(
+		"SELECT emoji FROM reactions WHERE message_id = $1 AND user_id = $2 AND emoji IN ($3, $4) LIMIT 1",
+		messageID, userID, reactionLike, reactionDislike,
+	).Scan(&prevEmoji)
 	if err != nil && err != sql.ErrNoRows {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to like message"})
-		return
+		return 0, res, err
 	}
-	if existingType != nil {
-		if *existingType == 1 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "You have already liked this message"})
-		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "You have already disliked this message"})
+	prev := emojiVote(prevEmoji)
+	if check != nil {
+		if err := check(prev); err != nil {
+			return 0, res, err
 		}
-		return
 	}
 
-	if _, err := h.db.Exec("INSERT INTO reactions (message_id, user_id, type) VALUES ($1, $2, 1)", messageID, userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to like message"})
-		return
+	if prev != value {
+		if _, err := tx.Exec(
+			"DELETE FROM reactions WHERE message_id = $1 AND user_id = $2 AND emoji IN ($3, $4)",
+			messageID, userID, reactionLike, reactionDislike,
+		); err != nil {
+			return 0, res, err
+		}
+		if value != 0 {
+			if _, err := tx.Exec(
+				"INSERT INTO reactions (message_id, user_id, emoji) VALUES ($1, $2, $3)",
+				messageID, userID, voteEmoji[value],
+			); err != nil {
+				return 0, res, err
+			}
+		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Message liked"})
+	err = tx.QueryRow(
+		`SELECT COUNT(*) FILTER (WHERE emoji = $2), COUNT(*) FILTER (WHERE emoji = $3)
+		 FROM reactions WHERE message_id = $1`,
+		messageID, reactionLike, reactionDislike,
+	).Scan(&res.Likes, &res.Dislikes)
+	if err != nil {
+		return 0, res, err
+	}
+
+	return receiverID, res, tx.Commit()
 }
 
-func (h *LikeHandler) RemoveLike(c *gin.Context) {
-	messageID, ok := h.parseMessageID(c)
-	if !ok {
-		return
-	}
-	userID, ok := h.requireAuth(c)
-	if !ok {
-		return
+// vote checks that the user may cast value on the message, applies it and
+// writes an error response if anything fails.
+func (h *LikeHandler) vote(c *gin.Context, messageID, userID int64, value int, check func(prev int) error, failMsg, ownMsg string) (model.VoteResponse, bool) {
+	if value != 0 {
+		receiverID, ok := reactionTarget(c, h.db, messageID, userID, failMsg, ownMsg)
+		if !ok {
+			return model.VoteResponse{}, false
+		}
+		if !requireReactionAllowed(c, h.db, receiverID, voteEmoji[value], failMsg) {
+			return model.VoteResponse{}, false
+		}
 	}
 
-	var exists bool
-	h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM messages WHERE id = $1)", messageID).Scan(&exists)
-	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
-		return
+	var refused error
+	receiverID, res, err := applyVote(h.db, messageID, userID, value, func(prev int) error {
+		if check != nil {
+			refused = check(prev)
+		}
+		return refused
+	})
+	if refused != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, refused.Error())})
+		return res, false
 	}
-
-	result, err := h.db.Exec("DELETE FROM reactions WHERE message_id = $1 AND user_id = $2 AND type = 1", messageID, userID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove like"})
-		return
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "Message not found")})
+		return res, false
 	}
-
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Message not liked"})
-		return
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, failMsg)})
+		return res, false
 	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Like removed"})
+	h.cache.Invalidate(receiverID)
+	return res, true
 }
 
-func (h *LikeHandler) Dislike(c *gin.Context) {
-	messageID, ok := h.parseMessageID(c)
+// Vote sets the caller's vote on a message to the value in the body: 1 to
+// like, -1 to dislike or 0 to clear it. It responds with the new counts.
+func (h *LikeHandler) Vote(c *gin.Context) {
+	messageID, ok := parseMessageID(c)
 	if !ok {
 		return
 	}
-	userID, ok := h.requireAuth(c)
+	userID, ok := requireAuth(c)
 	if !ok {
 		return
 	}
 
-	var authorID int64
-	err := h.db.QueryRow("SELECT author_id FROM messages WHERE id = $1", messageID).Scan(&authorID)
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
-		return
+	var req struct {
+		Value *int `json:"value"`
 	}
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dislike message"})
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
 		return
 	}
-
-	if authorID == userID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "You can't dislike your own message"})
+	if req.Value == nil || *req.Value < -1 || *req.Value > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "Vote must be -1, 0 or 1")})
 		return
 	}
 
-	var existingType *int16
-	err = h.db.QueryRow("SELECT type FROM reactions WHERE message_id = $1 AND user_id = $2", messageID, userID).Scan(&existingType)
-	if err != nil && err != sql.ErrNoRows {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dislike message"})
-		return
-	}
-	if existingType != nil {
-		if *existingType == -1 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "You have already disliked this message"})
-		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "You have already liked this message"})
-		}
+	res, ok := h.vote(c, messageID, userID, *req.Value, nil, "Failed to vote", "You can't vote on your own message")
+	if !ok {
 		return
 	}
 
-	if _, err := h.db.Exec("INSERT INTO reactions (message_id, user_id, type) VALUES ($1, $2, -1)", messageID, userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dislike message"})
-		return
-	}
+	c.JSON(http.StatusOK, res)
+}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Message disliked"})
+// expectVote returns a check that refuses the change unless the previous
+// vote was want.
+func expectVote(want int) func(prev int) error {
+	return func(prev int) error {
+		switch {
+		case prev == want:
+			return nil
+		case want == 1:
+			return errNotLiked
+		case want == -1:
+			return errNotDisliked
+		case prev == 1:
+			return errAlreadyLiked
+		default:
+			return errAlreadyDisliked
+		}
+	}
 }
 
-func (h *LikeHandler) RemoveDislike(c *gin.Context) {
-	messageID, ok := h.parseMessageID(c)
+// legacyVote backs the single-purpose endpoints, which change the vote from
+// from to value.
+func (h *LikeHandler) legacyVote(c *gin.Context, from, value int, failMsg, ownMsg, doneMsg string) {
+	messageID, ok := parseMessageID(c)
 	if !ok {
 		return
 	}
-	userID, ok := h.requireAuth(c)
+	userID, ok := requireAuth(c)
 	if !ok {
 		return
 	}
 
-	var exists bool
-	h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM messages WHERE id = $1)", messageID).Scan(&exists)
-	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+	if _, ok := h.vote(c, messageID, userID, value, expectVote(from), failMsg, ownMsg); !ok {
 		return
 	}
 
-	result, err := h.db.Exec("DELETE FROM reactions WHERE message_id = $1 AND user_id = $2 AND type = -1", messageID, userID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove dislike"})
-		return
-	}
+	c.JSON(http.StatusOK, gin.H{"message": doneMsg})
+}
 
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Message not disliked"})
-		return
-	}
+func (h *LikeHandler) Like(c *gin.Context) {
+	h.legacyVote(c, 0, 1, "Failed to like message", "You can't like your own message", "Message liked")
+}
+
+func (h *LikeHandler) RemoveLike(c *gin.Context) {
+	h.legacyVote(c, 1, 0, "Failed to remove like", "", "Like removed")
+}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Dislike removed"})
+func (h *LikeHandler) Dislike(c *gin.Context) {
+	h.legacyVote(c, 0, -1, "Failed to dislike message", "You can't dislike your own message", "Message disliked")
+}
+
+func (h *LikeHandler) RemoveDislike(c *gin.Context) {
+	h.legacyVote(c, -1, 0, "Failed to remove dislike", "", "Dislike removed")
 }
 
 func (h *LikeHandler) OwnerLike(c *gin.Context) {
-	messageID, ok := h.parseMessageID(c)
+	messageID, ok := parseMessageID(c)
 	if !ok {
 		return
 	}
-	userID, ok := h.requireAuth(c)
+	userID, ok := requireAuth(c)
 	if !ok {
 		return
 	}
@@ -212,7 +262,7 @@ func (h *LikeHandler) OwnerLike(c *gin.Context) {
 		messageID, userID,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to like message"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to like message")})
 		return
 	}
 
@@ -221,30 +271,31 @@ func (h *LikeHandler) OwnerLike(c *gin.Context) {
 		var exists bool
 		h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM messages WHERE id = $1)", messageID).Scan(&exists)
 		if !exists {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+			c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "Message not found")})
 			return
 		}
 
 		var isOwner bool
 		h.db.QueryRow("SELECT receiver_id = $2 FROM messages WHERE id = $1", messageID, userID).Scan(&isOwner)
 		if !isOwner {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "You can only like your own message"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "You can only like your own message")})
 			return
 		}
 
-		c.JSON(http.StatusBadRequest, gin.H{"error": "You have already liked message"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "You have already liked message")})
 		return
 	}
+	h.cache.Invalidate(userID)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Message liked"})
 }
 
 func (h *LikeHandler) OwnerRemoveLike(c *gin.Context) {
-	messageID, ok := h.parseMessageID(c)
+	messageID, ok := parseMessageID(c)
 	if !ok {
 		return
 	}
-	userID, ok := h.requireAuth(c)
+	userID, ok := requireAuth(c)
 	if !ok {
 		return
 	}
@@ -254,7 +305,7 @@ func (h *LikeHandler) OwnerRemoveLike(c *gin.Context) {
 		messageID, userID,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove like"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to remove like")})
 		return
 	}
 
@@ -263,20 +314,21 @@ func (h *LikeHandler) OwnerRemoveLike(c *gin.Context) {
 		var exists bool
 		h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM messages WHERE id = $1)", messageID).Scan(&exists)
 		if !exists {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+			c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "Message not found")})
 			return
 		}
 
 		var isOwner bool
 		h.db.QueryRow("SELECT receiver_id = $2 FROM messages WHERE id = $1", messageID, userID).Scan(&isOwner)
 		if !isOwner {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "You can only remove like from your own message"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "You can only remove like from your own message")})
 			return
 		}
 
-		c.JSON(http.StatusBadRequest, gin.H{"error": "You have not liked this message"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "You have not liked this message")})
 		return
 	}
+	h.cache.Invalidate(userID)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Like removed"})
 }
@@ -2,30 +2,95 @@ package handler
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"net/http"
-	"regexp"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/in-jun/github-profile-guestbook/internal/filter"
+	"github.com/in-jun/github-profile-guestbook/internal/markup"
 	"github.com/in-jun/github-profile-guestbook/internal/model"
 	"github.com/lib/pq"
 )
 
-var zalgoPattern = regexp.MustCompile(`[\p{Mn}\p{Me}\p{Mc}]`)
+const (
+	maxContentLength = 200
+	maxReplyLength   = 100
+
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// parsePage reads the limit and offset query parameters of lists paged by
+// offset.
+func parsePage(c *gin.Context) (limit, offset int, err error) {
+	limit = defaultListLimit
+	if q := c.Query("limit"); q != "" {
+		limit, err = strconv.Atoi(q)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			return 0, 0, errInvalidLimit
+		}
+	}
+	if q := c.Query("offset"); q != "" {
+		offset, err = strconv.Atoi(q)
+		if err != nil || offset < 0 {
+			return 0, 0, errInvalidOffset
+		}
+	}
+	return limit, offset, nil
+}
 
 type MessageHandler struct {
-	db *sql.DB
+	db         *sql.DB
+	cache      *RenderCache
+	editWindow time.Duration
+	filters    filter.Chain
 }
 
-func NewMessageHandler(db *sql.DB) *MessageHandler {
-	return &MessageHandler{db: db}
+type MessageHandlerConfig struct {
+	EditWindow int
+	// ContentFilter runs after the basic checks on new and edited messages.
+	// Since it may rewrite the content, the basic checks run again after it.
+	ContentFilter filter.ContentFilter
+}
+
+func NewMessageHandler(db *sql.DB, cache *RenderCache, cfg *MessageHandlerConfig) *MessageHandler {
+	filters := filter.Basic(maxContentLength)
+	if cfg.ContentFilter != nil {
+		filters = append(filters, cfg.ContentFilter)
+		filters = append(filters, filter.Basic(maxContentLength)...)
+	}
+	return &MessageHandler{
+		db:         db,
+		cache:      cache,
+		editWindow: time.Duration(cfg.EditWindow) * time.Second,
+		filters:    filters,
+	}
+}
+
+// filterContent runs content through the message filters, checking the
+// owner's blocked words too. If the content is rejected or can't be checked
+// it writes the error response itself, reporting failMsg for the latter.
+func (h *MessageHandler) filterContent(c *gin.Context, content string, settings model.GuestbookSettings, failMsg string) (string, bool) {
+	content, err := h.filters.Filter(c.Request.Context(), filter.Message{Content: content, OwnerWords: settings.BlockedWords})
+	if filter.IsRejection(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+		return "", false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, failMsg)})
+		return "", false
+	}
+	return content, true
 }
 
 func (h *MessageHandler) Create(c *gin.Context) {
 	username := c.Param("username")
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "Unauthorized")})
 		return
 	}
 	authorID := userID.(int64)
@@ -34,100 +99,236 @@ func (h *MessageHandler) Create(c *gin.Context) {
 		Content string `json:"content"`
 	}
 	if err := c.BindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+
+	var receiverID int64
+	err := h.db.QueryRow("SELECT id FROM users WHERE github_login = $1", username).Scan(&receiverID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "GitHub user not found")})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to create message")})
 		return
 	}
 
-	if req.Content == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Content not provided"})
+	if !requireNotBlocked(c, h.db, receiverID, authorID, "Failed to create message") {
 		return
 	}
 
-	runes := []rune(req.Content)
-	if len(runes) > 200 {
-		req.Content = string(runes[:200])
+	settings, err := loadSettings(h.db, receiverID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to create message")})
+		return
+	}
+	content, ok := h.filterContent(c, req.Content, settings, "Failed to create message")
+	if !ok {
+		return
 	}
 
-	if zalgoPattern.MatchString(req.Content) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid content"})
+	status, err := initialStatus(h.db, settings, receiverID, authorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to create message")})
 		return
 	}
 
 	// Store raw content in DB, escape only when rendering (SVG, HTML)
-	_, err := h.db.Exec(
-		`INSERT INTO messages (receiver_id, author_id, content)
-		 VALUES ((SELECT id FROM users WHERE github_login = $1), $2, $3)`,
-		username, authorID, req.Content,
+	_, err = h.db.Exec(
+		"INSERT INTO messages (receiver_id, author_id, content, status) VALUES ($1, $2, $3, $4)",
+		receiverID, authorID, content, status,
 	)
 	if err != nil {
 		if isUniqueViolation(err) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "user already has a message"})
-		} else if isNullViolation(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "GitHub user not found"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "user already has a message")})
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create message"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to create message")})
 		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Message created"})
+	if status == messagePending {
+		c.JSON(http.StatusOK, gin.H{"message": "Message awaiting approval", "status": status})
+		return
+	}
+	h.cache.Invalidate(receiverID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message created", "status": status})
 }
 
 func (h *MessageHandler) List(c *gin.Context) {
 	username := c.Param("username")
 
-	var exists bool
-	h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE github_login = $1)", username).Scan(&exists)
-	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "GitHub user not found"})
+	var receiverID int64
+	err := h.db.QueryRow("SELECT id FROM users WHERE github_login = $1", username).Scan(&receiverID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "GitHub user not found")})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get messages")})
 		return
 	}
 
+	settings, err := loadSettings(h.db, receiverID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get messages")})
+		return
+	}
+	sort, err := parseSort(c, settings)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+	filter, err := parseMessageFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+
+	limit := defaultListLimit
+	if q := c.Query("limit"); q != "" {
+		limit, err = strconv.Atoi(q)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, errInvalidLimit.Error())})
+			return
+		}
+	}
+
 	var currentUserID *int64
 	if uid, ok := c.Get("user_id"); ok {
 		id := uid.(int64)
 		currentUserID = &id
 	}
 
-	query := `SELECT
-		m.id,
-		a.github_login AS author_login,
-		a.id           AS author_id,
-		m.content,
-		m.is_owner_liked,
-		COUNT(CASE WHEN r.type = 1 THEN 1 END)                     AS likes,
-		COUNT(CASE WHEN r.type = -1 THEN 1 END)                    AS dislikes,
-		COALESCE(BOOL_OR(r.user_id = $2 AND r.type = 1), FALSE)    AS is_liked,
-		COALESCE(BOOL_OR(r.user_id = $2 AND r.type = -1), FALSE)   AS is_disliked
-	FROM messages m
-	JOIN users a          ON a.id = m.author_id
-	JOIN users recv       ON recv.id = m.receiver_id
-	LEFT JOIN reactions r ON r.message_id = m.id
-	WHERE recv.github_login = $1
-	GROUP BY m.id, a.github_login, a.id, m.content, m.is_owner_liked
-	ORDER BY
-		CASE WHEN a.id = $2 THEN 0 ELSE 1 END,
-		m.is_owner_liked DESC,
-		(COUNT(CASE WHEN r.type = 1 THEN 1 END) - COUNT(CASE WHEN r.type = -1 THEN 1 END)) DESC`
-
-	rows, err := h.db.Query(query, username, currentUserID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get messages"})
+	q := newMessageQuery(receiverID, currentUserID)
+	q.noDislikes = !countsDislikes(settings)
+	q.filter(filter)
+
+	var total, starred int
+	if err := h.db.QueryRow(q.countSQL(), q.args...).Scan(&total, &starred); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get messages")})
+		return
+	}
+
+	viewer := q.arg(currentUserID)
+	// The caller's own message always comes first.
+	q.orderFirst(sortTerm{expr: "CASE WHEN m.author_id = " + viewer + " THEN 0 ELSE 1 END", typ: "integer"})
+
+	// Later pages keep the shuffle day of the first one.
+	day := shuffleDay(time.Now())
+	var mc *messageCursor
+	if cursor := c.Query("cursor"); cursor != "" {
+		decoded, err := decodeCursor(cursor, sort)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		mc = &decoded
+		if sort == sortRandomDaily {
+			day = mc.Day
+		}
+	}
+	q.sortBy(sort, day)
+	if mc != nil {
+		if err := q.after(mc.Keys); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+	}
+
+	columns := append([]string{
+		"m.id",
+		"a.github_login",
+		"a.id",
+		"m.content",
+		"m.is_owner_liked",
+		"rc.likes",
+		"rc.dislikes",
+		"m.status",
+		"m.pin_position",
+		"m.hidden_at IS NOT NULL",
+		"m.created_at",
+		"m.edited_at",
+		"rp.content",
+		"rp.created_at",
+		"rp.updated_at",
+		"EXISTS(SELECT 1 FROM reactions WHERE message_id = m.id AND user_id = " + viewer + " AND emoji = '" + reactionLike + "')",
+		"EXISTS(SELECT 1 FROM reactions WHERE message_id = m.id AND user_id = " + viewer + " AND emoji = '" + reactionDislike + "')",
+		reactionCountsColumn(viewer),
+	}, q.keyColumns()...)
+
+	// One extra row tells whether there is a next page.
+	rows, err := h.db.Query(q.selectSQL(limit+1, 0, columns...), q.args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get messages")})
 		return
 	}
 	defer rows.Close()
 
-	messages := make([]model.MessageResponse, 0)
+	messages := make([]model.MessageResponse, 0, limit)
+	enabledReactions := allowedReactions(settings)
+	var lastKeys []string
+	hasMore := false
 	for rows.Next() {
+		if len(messages) == limit {
+			hasMore = true
+			break
+		}
+
 		var mr model.MessageResponse
 		var authorID int64
-		if err := rows.Scan(&mr.ID, &mr.Author, &authorID, &mr.Content, &mr.IsOwnerLiked, &mr.Likes, &mr.Dislikes, &mr.IsLiked, &mr.IsDisliked); err != nil {
-			continue
+		var replyContent sql.NullString
+		var replyCreatedAt, replyUpdatedAt sql.NullTime
+		var reactions []byte
+		keys := make([]string, len(q.order))
+		dest := []any{&mr.ID, &mr.Author, &authorID, &mr.Content, &mr.IsOwnerLiked, &mr.Likes, &mr.Dislikes, &mr.Status, &mr.PinPosition, &mr.Hidden, &mr.CreatedAt, &mr.EditedAt,
+			&replyContent, &replyCreatedAt, &replyUpdatedAt, &mr.IsLiked, &mr.IsDisliked, &reactions}
+		for i := range keys {
+			dest = append(dest, &keys[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get messages")})
+			return
+		}
+		if err := json.Unmarshal(reactions, &mr.Reactions); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get messages")})
+			return
+		}
+		mr.EnabledReactions = enabledReactions
+		mr.Rendered = markup.Parse(mr.Content)
+		if replyContent.Valid {
+			mr.Reply = &model.MessageReply{
+				Content:   replyContent.String,
+				CreatedAt: replyCreatedAt.Time,
+				UpdatedAt: replyUpdatedAt.Time,
+			}
 		}
 		messages = append(messages, mr)
+		lastKeys = keys
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get messages")})
+		return
 	}
 
-	c.JSON(http.StatusOK, messages)
+	var nextCursor *string
+	if hasMore {
+		next := messageCursor{Sort: sort, Keys: lastKeys}
+		if sort == sortRandomDaily {
+			next.Day = day
+		}
+		encoded := next.encode()
+		nextCursor = &encoded
+	}
+
+	c.JSON(http.StatusOK, model.MessageListResponse{
+		Items:      messages,
+		NextCursor: nextCursor,
+		Total:      total,
+		Reactions:  enabledReactions,
+	})
 }
 
 func (h *MessageHandler) Delete(c *gin.Context) {
@@ -135,7 +336,7 @@ func (h *MessageHandler) Delete(c *gin.Context) {
 
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "Unauthorized")})
 		return
 	}
 	authorID := userID.(int64)
@@ -143,42 +344,207 @@ func (h *MessageHandler) Delete(c *gin.Context) {
 	var receiverExists bool
 	h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE github_login = $1)", username).Scan(&receiverExists)
 	if !receiverExists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "GitHub user not found"})
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "GitHub user not found")})
 		return
 	}
 
-	result, err := h.db.Exec(
+	var receiverID int64
+	err := h.db.QueryRow(
 		`DELETE FROM messages
 		 WHERE receiver_id = (SELECT id FROM users WHERE github_login = $1)
-		   AND author_id = $2`,
+		   AND author_id = $2
+		 RETURNING receiver_id`,
 		username, authorID,
-	)
+	).Scan(&receiverID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "Message not found")})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete message"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to delete message")})
 		return
 	}
+	h.cache.Invalidate(receiverID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message deleted"})
+}
+
+func (h *MessageHandler) Edit(c *gin.Context) {
+	username := c.Param("username")
 
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "Unauthorized")})
 		return
 	}
+	authorID := userID.(int64)
 
-	c.JSON(http.StatusOK, gin.H{"message": "Message deleted"})
+	var req struct {
+		Content string `json:"content"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+
+	var receiverID int64
+	err := h.db.QueryRow("SELECT id FROM users WHERE github_login = $1", username).Scan(&receiverID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "GitHub user not found")})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to edit message")})
+		return
+	}
+	if !requireNotBlocked(c, h.db, receiverID, authorID, "Failed to edit message") {
+		return
+	}
+
+	settings, err := loadSettings(h.db, receiverID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to edit message")})
+		return
+	}
+	content, ok := h.filterContent(c, req.Content, settings, "Failed to edit message")
+	if !ok {
+		return
+	}
+	// An edit is checked like a new message, so a published message goes back
+	// to the queue if the owner would hold a new one from this author.
+	editStatus, err := initialStatus(h.db, settings, receiverID, authorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to edit message")})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to edit message")})
+		return
+	}
+	defer tx.Rollback()
+
+	var messageID int64
+	var previous, status string
+	var createdAt time.Time
+	err = tx.QueryRow(
+		"SELECT id, content, status, created_at FROM messages WHERE receiver_id = $1 AND author_id = $2 FOR UPDATE",
+		receiverID, authorID,
+	).Scan(&messageID, &previous, &status, &createdAt)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "Message not found")})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to edit message")})
+		return
+	}
+
+	if status == messageRemoved {
+		c.JSON(http.StatusForbidden, gin.H{"error": tr(c, "Message was removed by the guestbook owner")})
+		return
+	}
+	if h.editWindow > 0 && time.Since(createdAt) > h.editWindow {
+		c.JSON(http.StatusForbidden, gin.H{"error": tr(c, "Edit window has expired")})
+		return
+	}
+
+	if content == previous {
+		c.JSON(http.StatusOK, gin.H{"message": "Message updated"})
+		return
+	}
+
+	if _, err := tx.Exec("INSERT INTO message_revisions (message_id, content) VALUES ($1, $2)", messageID, previous); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to edit message")})
+		return
+	}
+	if editStatus == messagePending {
+		status = messagePending
+	}
+	if _, err := tx.Exec(
+		`UPDATE messages SET content = $1, edited_at = NOW(), status = $3,
+		        pin_position = CASE WHEN $3 = '`+messagePublished+`' THEN pin_position END
+		 WHERE id = $2`,
+		content, messageID, status,
+	); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to edit message")})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to edit message")})
+		return
+	}
+	h.cache.Invalidate(receiverID)
+
+	if status == messagePending {
+		c.JSON(http.StatusOK, gin.H{"message": "Message awaiting approval", "status": status})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Message updated", "status": status})
 }
 
-func isUniqueViolation(err error) bool {
-	var pqErr *pq.Error
-	if errors.As(err, &pqErr) {
-		return pqErr.Code == "23505"
+// Revisions lists a message's previous contents, newest first. Only the
+// guestbook owner and the author may see them.
+func (h *MessageHandler) Revisions(c *gin.Context) {
+	messageID, ok := parseMessageID(c)
+	if !ok {
+		return
 	}
-	return false
+	userID, ok := requireAuth(c)
+	if !ok {
+		return
+	}
+
+	var allowed bool
+	err := h.db.QueryRow(
+		"SELECT receiver_id = $2 OR author_id = $2 FROM messages WHERE id = $1",
+		messageID, userID,
+	).Scan(&allowed)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "Message not found")})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get revisions")})
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": tr(c, "You can't view this message's revisions")})
+		return
+	}
+
+	rows, err := h.db.Query(
+		"SELECT id, content, created_at FROM message_revisions WHERE message_id = $1 ORDER BY id DESC",
+		messageID,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get revisions")})
+		return
+	}
+	defer rows.Close()
+
+	revisions := make([]model.MessageRevision, 0)
+	for rows.Next() {
+		var rev model.MessageRevision
+		if err := rows.Scan(&rev.ID, &rev.Content, &rev.CreatedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get revisions")})
+			return
+		}
+		revisions = append(revisions, rev)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get revisions")})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
 }
 
-func isNullViolation(err error) bool {
+func isUniqueViolation(err error) bool {
 	var pqErr *pq.Error
 	if errors.As(err, &pqErr) {
-		return pqErr.Code == "23502"
+		return pqErr.Code == "23505"
 	}
 	return false
 }
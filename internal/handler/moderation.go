@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/in-jun/github-profile-guestbook/internal/model"
+)
+
+const (
+	messagePublished = "published"
+	messagePending   = "pending"
+	messageRemoved   = "removed" // taken down by the owner; only the author sees it
+)
+
+// Moderation modes an owner can choose for new messages.
+const (
+	moderationOpen     = "open"     // publish everything right away
+	moderationApproved = "approved" // publish right away only from approved authors
+	moderationQueue    = "queue"    // hold everything for approval
+)
+
+var moderationModes = map[string]bool{
+	moderationOpen:     true,
+	moderationApproved: true,
+	moderationQueue:    true,
+}
+
+var errUnknownModeration = errors.New("Unknown moderation mode")
+
+// initialStatus decides whether a new message is published right away or
+// held for the owner's approval. Owners never wait on themselves.
+func initialStatus(db *sql.DB, settings model.GuestbookSettings, receiverID, authorID int64) (string, error) {
+	if authorID == receiverID {
+		return messagePublished, nil
+	}
+	mode := moderationOpen
+	if settings.Moderation != nil {
+		mode = *settings.Moderation
+	}
+
+	switch mode {
+	case moderationQueue:
+		return messagePending, nil
+	case moderationApproved:
+		var approved bool
+		err := db.QueryRow(
+			"SELECT EXISTS(SELECT 1 FROM approved_authors WHERE receiver_id = $1 AND author_id = $2)",
+			receiverID, authorID,
+		).Scan(&approved)
+		if err != nil {
+			return "", err
+		}
+		if !approved {
+			return messagePending, nil
+		}
+	}
+	return messagePublished, nil
+}
+
+// ModerationHandler lets owners review the messages held on their own
+// guestbook.
+type ModerationHandler struct {
+	db    *sql.DB
+	cache *RenderCache
+}
+
+func NewModerationHandler(db *sql.DB, cache *RenderCache) *ModerationHandler {
+	return &ModerationHandler{db: db, cache: cache}
+}
+
+func (h *ModerationHandler) Pending(c *gin.Context) {
+	userID, ok := requireAuth(c)
+	if !ok {
+		return
+	}
+
+	rows, err := h.db.Query(
+		`SELECT m.id, a.github_login, m.content, m.created_at
+		 FROM messages m
+		 JOIN users a ON a.id = m.author_id
+		 WHERE m.receiver_id = $1 AND m.status = $2
+		 ORDER BY m.created_at, m.id`,
+		userID, messagePending,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get pending messages")})
+		return
+	}
+	defer rows.Close()
+
+	messages := make([]model.PendingMessage, 0)
+	for rows.Next() {
+		var pm model.PendingMessage
+		if err := rows.Scan(&pm.ID, &pm.Author, &pm.Content, &pm.CreatedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get pending messages")})
+			return
+		}
+		messages = append(messages, pm)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get pending messages")})
+		return
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
+
+// Approve publishes a pending message and approves its author, so their
+// later messages skip the queue in the approved-authors mode.
+func (h *ModerationHandler) Approve(c *gin.Context) {
+	messageID, ok := parseMessageID(c)
+	if !ok {
+		return
+	}
+	userID, ok := requireAuth(c)
+	if !ok {
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to approve message")})
+		return
+	}
+	defer tx.Rollback()
+
+	var authorID int64
+	err = tx.QueryRow(
+		`UPDATE messages SET status = $3
+		 WHERE id = $1 AND receiver_id = $2 AND status = $4
+		 RETURNING author_id`,
+		messageID, userID, messagePublished, messagePending,
+	).Scan(&authorID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "Pending message not found")})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to approve message")})
+		return
+	}
+
+	if _, err := tx.Exec(
+		"INSERT INTO approved_authors (receiver_id, author_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		userID, authorID,
+	); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to approve message")})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to approve message")})
+		return
+	}
+	h.cache.Invalidate(userID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message approved"})
+}
+
+// Reject deletes a pending message. It was never shown, so the rendered
+// guestbook is unaffected.
+func (h *ModerationHandler) Reject(c *gin.Context) {
+	messageID, ok := parseMessageID(c)
+	if !ok {
+		return
+	}
+	userID, ok := requireAuth(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.db.Exec(
+		"DELETE FROM messages WHERE id = $1 AND receiver_id = $2 AND status = $3",
+		messageID, userID, messagePending,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to reject message")})
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "Pending message not found")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message rejected"})
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// maxPinnedMessages limits how many messages an owner can pin.
+const maxPinnedMessages = 10
+
+// PinHandler lets owners pin messages to the top of their guestbook in an
+// order they choose. Pinning is separate from starring.
+type PinHandler struct {
+	db    *sql.DB
+	cache *RenderCache
+}
+
+func NewPinHandler(db *sql.DB, cache *RenderCache) *PinHandler {
+	return &PinHandler{db: db, cache: cache}
+}
+
+// Reorder replaces the caller's pinned messages with the given ones, in
+// order. Messages left out are unpinned, so an empty list unpins everything.
+func (h *PinHandler) Reorder(c *gin.Context) {
+	userID, ok := requireAuth(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		MessageIDs []int64 `json:"message_ids"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+	if len(req.MessageIDs) > maxPinnedMessages {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "Too many pinned messages")})
+		return
+	}
+	seen := make(map[int64]bool, len(req.MessageIDs))
+	for _, id := range req.MessageIDs {
+		if seen[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "Duplicate message ID")})
+			return
+		}
+		seen[id] = true
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to pin messages")})
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		"UPDATE messages SET pin_position = NULL WHERE receiver_id = $1 AND pin_position IS NOT NULL",
+		userID,
+	); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to pin messages")})
+		return
+	}
+
+	result, err := tx.Exec(
+		`UPDATE messages m SET pin_position = p.position
+		 FROM unnest($2::bigint[]) WITH ORDINALITY AS p(id, position)
+		 WHERE m.id = p.id AND m.receiver_id = $1 AND m.status = $3 AND m.hidden_at IS NULL`,
+		userID, pq.Array(req.MessageIDs), messagePublished,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to pin messages")})
+		return
+	}
+	if n, _ := result.RowsAffected(); n != int64(len(req.MessageIDs)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "Message not found")})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to pin messages")})
+		return
+	}
+	h.cache.Invalidate(userID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Pinned messages updated"})
+}
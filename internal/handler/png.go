@@ -0,0 +1,505 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomedium"
+	"golang.org/x/image/font/gofont/gomediumitalic"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/gomonobolditalic"
+	"golang.org/x/image/font/gofont/gomonoitalic"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// pngScale renders raster output at twice the layout size so it stays sharp
+// on high-density displays.
+const pngScale = 2
+
+// PNGs are drawn into memory, so they show fewer messages than SVGs and
+// refuse to grow past maxPNGHeight layout units, which fits maxPNGLimit
+// messages of the longest kind.
+const (
+	maxPNGLimit  = 10
+	maxPNGHeight = 2560
+)
+
+var (
+	errInvalidScheme = errors.New("Invalid color scheme")
+	errImageTooLarge = errors.New("Image is too large")
+)
+
+// fontVariant selects one of the bundled Go fonts.
+type fontVariant struct {
+	weight int
+	italic bool
+	mono   bool
+}
+
+var (
+	fontsOnce sync.Once
+	fontsErr  error
+	fonts     map[fontVariant]*opentype.Font
+
+	// fallbackFonts are consulted, in order, for characters the Go fonts
+	// lack, such as Hangul, kana and CJK ideographs.
+	fallbackFonts []*opentype.Font
+)
+
+func loadFonts() (map[fontVariant]*opentype.Font, error) {
+	fontsOnce.Do(func() {
+		sources := map[fontVariant][]byte{
+			{weight: 400}:                           goregular.TTF,
+			{weight: 500}:                           gomedium.TTF,
+			{weight: 700}:                           gobold.TTF,
+			{weight: 400, italic: true}:             goitalic.TTF,
+			{weight: 500, italic: true}:             gomediumitalic.TTF,
+			{weight: 700, italic: true}:             gobolditalic.TTF,
+			{weight: 400, mono: true}:               gomono.TTF,
+			{weight: 700, mono: true}:               gomonobold.TTF,
+			{weight: 400, italic: true, mono: true}: gomonoitalic.TTF,
+			{weight: 700, italic: true, mono: true}: gomonobolditalic.TTF,
+		}
+		fonts = make(map[fontVariant]*opentype.Font, len(sources))
+		for variant, ttf := range sources {
+			f, err := opentype.Parse(ttf)
+			if err != nil {
+				fontsErr = err
+				return
+			}
+			fonts[variant] = f
+		}
+	})
+	return fonts, fontsErr
+}
+
+// LoadFallbackFonts reads the TrueType or OpenType files (or the first font
+// of a collection) used for characters missing from the bundled Go fonts.
+func LoadFallbackFonts(paths []string) error {
+	loaded := make([]*opentype.Font, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		collection, err := opentype.ParseCollection(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		f, err := collection.Font(0)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		loaded = append(loaded, f)
+	}
+	fallbackFonts = loaded
+	return nil
+}
+
+func (t theme) palette(scheme string) palette {
+	if scheme == "dark" && t.dark != nil {
+		return *t.dark
+	}
+	return t.light
+}
+
+func parseColorScheme(c *gin.Context) (string, error) {
+	switch scheme := c.DefaultQuery("scheme", "light"); scheme {
+	case "light", "dark":
+		return scheme, nil
+	default:
+		return "", errInvalidScheme
+	}
+}
+
+// cssColor converts a palette value (a hex color or one of the named colors
+// used by the presets) into an RGBA color.
+func cssColor(s string) color.RGBA {
+	switch s {
+	case "white":
+		return color.RGBA{255, 255, 255, 255}
+	case "black", "":
+		return color.RGBA{0, 0, 0, 255}
+	}
+
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 || len(hex) == 4 {
+		var expanded strings.Builder
+		for _, ch := range hex {
+			expanded.WriteRune(ch)
+			expanded.WriteRune(ch)
+		}
+		hex = expanded.String()
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 8 {
+		return color.RGBA{0, 0, 0, 255}
+	}
+
+	// image/color expects premultiplied alpha.
+	a := uint32(v & 0xff)
+	premultiply := func(c uint32) uint8 { return uint8(c * a / 0xff) }
+	return color.RGBA{premultiply(uint32(v >> 24)), premultiply(uint32(v>>16) & 0xff), premultiply(uint32(v>>8) & 0xff), uint8(a)}
+}
+
+type faceKey struct {
+	variant  fontVariant
+	size     int
+	fallback int // index into fallbacks plus one, or zero for the Go fonts
+}
+
+type pngCanvas struct {
+	img       *image.RGBA
+	palette   palette
+	fonts     map[fontVariant]*opentype.Font
+	fallbacks []*opentype.Font
+	faces     map[faceKey]font.Face
+	// tooLarge is set when the layout asked for more than maxPNGHeight. The
+	// drawing then goes to a placeholder and encode fails.
+	tooLarge bool
+}
+
+func newPNGCanvas(p palette) (*pngCanvas, error) {
+	f, err := loadFonts()
+	if err != nil {
+		return nil, err
+	}
+	return &pngCanvas{palette: p, fonts: f, fallbacks: fallbackFonts, faces: make(map[faceKey]font.Face)}, nil
+}
+
+func (cv *pngCanvas) color(role colorRole) color.RGBA {
+	switch role {
+	case colorBg:
+		return cssColor(cv.palette.Bg)
+	case colorText:
+		return cssColor(cv.palette.Text)
+	case colorBorder:
+		return cssColor(cv.palette.Border)
+	case colorGray:
+		return cssColor(cv.palette.Gray)
+	case colorAccent:
+		return cssColor(cv.palette.Accent)
+	default:
+		return color.RGBA{}
+	}
+}
+
+func (cv *pngCanvas) face(style textStyle) font.Face {
+	return cv.faceFrom(style, 0)
+}
+
+func (cv *pngCanvas) faceFrom(style textStyle, fallback int) font.Face {
+	variant := fontVariant{weight: style.weight, italic: style.italic, mono: style.mono}
+	if _, ok := cv.fonts[variant]; !ok {
+		variant.weight = 400
+	}
+	src := cv.fonts[variant]
+	if fallback > 0 {
+		// Fallback fonts come in a single style.
+		variant = fontVariant{}
+		src = cv.fallbacks[fallback-1]
+	}
+	key := faceKey{variant: variant, size: style.size, fallback: fallback}
+	if f, ok := cv.faces[key]; ok {
+		return f
+	}
+	f, err := opentype.NewFace(src, &opentype.FaceOptions{
+		Size:    float64(style.size * pngScale),
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil
+	}
+	cv.faces[key] = f
+	return f
+}
+
+func (cv *pngCanvas) fill(r image.Rectangle, role colorRole) {
+	if role == colorNone {
+		return
+	}
+	draw.Draw(cv.img, r, image.NewUniform(cv.color(role)), image.Point{}, draw.Over)
+}
+
+func (cv *pngCanvas) begin(width, height int) {
+	if height > maxPNGHeight {
+		cv.tooLarge = true
+		cv.img = image.NewRGBA(image.Rect(0, 0, 1, 1))
+		return
+	}
+	cv.img = image.NewRGBA(image.Rect(0, 0, width*pngScale, height*pngScale))
+}
+
+func (cv *pngCanvas) rect(x, y, w, h int, fill, stroke colorRole) {
+	x0, y0, x1, y1 := x*pngScale, y*pngScale, (x+w)*pngScale, (y+h)*pngScale
+	cv.fill(image.Rect(x0, y0, x1, y1), fill)
+	if stroke == colorNone {
+		return
+	}
+	cv.fill(image.Rect(x0, y0, x1, y0+pngScale), stroke)
+	cv.fill(image.Rect(x0, y1-pngScale, x1, y1), stroke)
+	cv.fill(image.Rect(x0, y0+pngScale, x0+pngScale, y1-pngScale), stroke)
+	cv.fill(image.Rect(x1-pngScale, y0+pngScale, x1, y1-pngScale), stroke)
+}
+
+// polygonMask rasterizes a closed polygon into an alpha mask covering only
+// the polygon's bounding box.
+func polygonMask(points [][2]float32) *image.Alpha {
+	minX, minY := points[0][0], points[0][1]
+	maxX, maxY := minX, minY
+	for _, p := range points[1:] {
+		minX, maxX = min(minX, p[0]), max(maxX, p[0])
+		minY, maxY = min(minY, p[1]), max(maxY, p[1])
+	}
+	bounds := image.Rect(int(minX)-1, int(minY)-1, int(maxX)+2, int(maxY)+2)
+	ox, oy := float32(bounds.Min.X), float32(bounds.Min.Y)
+
+	z := vector.NewRasterizer(bounds.Dx(), bounds.Dy())
+	z.MoveTo(points[0][0]-ox, points[0][1]-oy)
+	for _, p := range points[1:] {
+		z.LineTo(p[0]-ox, p[1]-oy)
+	}
+	z.ClosePath()
+
+	mask := image.NewAlpha(bounds)
+	z.Draw(mask, bounds, image.Opaque, image.Point{})
+	return mask
+}
+
+func circleMask(cx, cy, r float32) *image.Alpha {
+	const segments = 64
+
+	points := make([][2]float32, segments)
+	for i := range points {
+		angle := 2 * math.Pi * float64(i) / segments
+		points[i] = [2]float32{cx + r*float32(math.Cos(angle)), cy + r*float32(math.Sin(angle))}
+	}
+	return polygonMask(points)
+}
+
+func (cv *pngCanvas) circle(cx, cy, r int, fill colorRole) {
+	if fill == colorNone {
+		return
+	}
+	mask := circleMask(float32(cx*pngScale), float32(cy*pngScale), float32(r*pngScale))
+	draw.DrawMask(cv.img, mask.Bounds(), image.NewUniform(cv.color(fill)), image.Point{}, mask, mask.Bounds().Min, draw.Over)
+}
+
+func (cv *pngCanvas) avatar(x, y, size int, img *avatarImage) {
+	target := image.Rect(x*pngScale, y*pngScale, (x+size)*pngScale, (y+size)*pngScale)
+	scaled := image.NewRGBA(target)
+	xdraw.CatmullRom.Scale(scaled, target, img.decoded, img.decoded.Bounds(), xdraw.Src, nil)
+
+	half := float32(size*pngScale) / 2
+	mask := circleMask(float32(target.Min.X)+half, float32(target.Min.Y)+half, half)
+	draw.DrawMask(cv.img, target, scaled, target.Min, mask, target.Min, draw.Over)
+}
+
+func (cv *pngCanvas) line(x1, y1, x2, y2 int, stroke colorRole) {
+	half := pngScale / 2
+	r := image.Rect(x1*pngScale, y1*pngScale-half, x2*pngScale, y2*pngScale+half)
+	if x1 == x2 {
+		r = image.Rect(x1*pngScale-half, y1*pngScale, x2*pngScale+half, y2*pngScale)
+	}
+	cv.fill(r.Canon(), stroke)
+}
+
+// glyphFace returns the face that should draw r: the Go font for style if it
+// has the glyph, otherwise the first fallback font that does. ok is false
+// when no font has it.
+func (cv *pngCanvas) glyphFace(style textStyle, r rune) (face font.Face, ok bool) {
+	primary := cv.face(style)
+	if _, ok := primary.GlyphAdvance(r); ok {
+		return primary, true
+	}
+	for i := range cv.fallbacks {
+		f := cv.faceFrom(style, i+1)
+		if f == nil {
+			continue
+		}
+		if _, ok := f.GlyphAdvance(r); ok {
+			return f, true
+		}
+	}
+	return primary, false
+}
+
+func (cv *pngCanvas) advance(style textStyle, s string, letterSpacing fixed.Int26_6) fixed.Int26_6 {
+	var width fixed.Int26_6
+	var prevFace font.Face
+	prev := rune(-1)
+	for _, r := range s {
+		face, ok := cv.glyphFace(style, r)
+		if prev >= 0 && face == prevFace {
+			width += face.Kern(prev, r)
+		}
+		adv, _ := face.GlyphAdvance(r)
+		if !ok && r == '★' {
+			adv = face.Metrics().Height * 3 / 4
+		}
+		width += adv + letterSpacing
+		prev, prevFace = r, face
+	}
+	return width
+}
+
+func (cv *pngCanvas) text(x, y int, s string, style textStyle) {
+	face := cv.face(style)
+	if face == nil {
+		return
+	}
+	letterSpacing := fixed.Int26_6(style.letterSpacing * pngScale * 64)
+
+	dot := fixed.P(x*pngScale, y*pngScale)
+	switch style.anchor {
+	case anchorMiddle:
+		dot.X -= cv.advance(style, s, letterSpacing) / 2
+	case anchorEnd:
+		dot.X -= cv.advance(style, s, letterSpacing)
+	}
+	if style.middle {
+		// dominant-baseline="middle" centers the x-height on y.
+		dot.Y += face.Metrics().XHeight / 2
+	}
+	cv.drawText(dot, s, style)
+}
+
+// drawText draws s with its baseline starting at dot and returns where the
+// next character would go.
+func (cv *pngCanvas) drawText(dot fixed.Point26_6, s string, style textStyle) fixed.Point26_6 {
+	face := cv.face(style)
+	if face == nil {
+		return dot
+	}
+	letterSpacing := fixed.Int26_6(style.letterSpacing * pngScale * 64)
+
+	src := image.NewUniform(cv.color(style.color))
+	drawer := &font.Drawer{Dst: cv.img, Src: src, Dot: dot}
+	prev := rune(-1)
+	for _, r := range s {
+		glyph, ok := cv.glyphFace(style, r)
+		if prev >= 0 && glyph == drawer.Face {
+			drawer.Dot.X += glyph.Kern(prev, r)
+		}
+		drawer.Face = glyph
+		if !ok && r == '★' {
+			size := face.Metrics().Height * 3 / 4
+			cv.star(drawer.Dot.X+size/2, drawer.Dot.Y-face.Metrics().XHeight/2, size/2, src)
+			drawer.Dot.X += size
+		} else {
+			drawer.DrawString(string(r))
+		}
+		drawer.Dot.X += letterSpacing
+		prev = r
+	}
+	return drawer.Dot
+}
+
+func (cv *pngCanvas) textLines(x, y, lineHeight int, lines [][]textRun, style textStyle) {
+	for i, line := range lines {
+		dot := fixed.P(x*pngScale, (y+i*lineHeight)*pngScale)
+		for _, run := range line {
+			dot = cv.drawText(dot, run.text, run.style(style))
+		}
+	}
+}
+
+// star fills a five-pointed star, which the bundled Go fonts lack a glyph for.
+func (cv *pngCanvas) star(cx, cy, radius fixed.Int26_6, src image.Image) {
+	outer := float32(radius) / 64
+	inner := outer * 0.4
+	x, y := float32(cx)/64, float32(cy)/64
+
+	points := make([][2]float32, 10)
+	for i := range points {
+		r := outer
+		if i%2 == 1 {
+			r = inner
+		}
+		px, py := starPoint(i, r)
+		points[i] = [2]float32{x + px, y + py}
+	}
+
+	mask := polygonMask(points)
+	draw.DrawMask(cv.img, mask.Bounds(), src, image.Point{}, mask, mask.Bounds().Min, draw.Over)
+}
+
+func starPoint(i int, radius float32) (float32, float32) {
+	angle := -math.Pi/2 + float64(i)*math.Pi/5
+	return radius * float32(math.Cos(angle)), radius * float32(math.Sin(angle))
+}
+
+// A PNG can't animate, so only the first slide is drawn.
+func (cv *pngCanvas) slides(int, time.Duration) {}
+
+func (cv *pngCanvas) slide(i int) bool {
+	return i == 0
+}
+
+func (cv *pngCanvas) endSlides() {}
+
+func (cv *pngCanvas) encode() ([]byte, error) {
+	if cv.tooLarge {
+		return nil, errImageTooLarge
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, cv.img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (h *SVGHandler) GetPNG(c *gin.Context) {
+	scheme, err := parseColorScheme(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+
+	if q := c.Query("limit"); q != "" {
+		if limit, err := strconv.Atoi(q); err == nil && limit > maxPNGLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, errInvalidLimit.Error())})
+			return
+		}
+	}
+
+	h.serveCard(c, "png", "image/png", func(card *guestbookCard) ([]byte, error) {
+		// The owner's saved limit is meant for the SVG; cut it down.
+		if len(card.messages) > maxPNGLimit {
+			card.remaining += len(card.messages) - maxPNGLimit
+			card.messages = card.messages[:maxPNGLimit]
+		}
+		cv, err := newPNGCanvas(card.theme.palette(scheme))
+		if err != nil {
+			return nil, err
+		}
+		card.draw(cv)
+		return cv.encode()
+	})
+}
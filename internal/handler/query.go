@@ -0,0 +1,311 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/in-jun/github-profile-guestbook/internal/model"
+)
+
+const defaultSort = "top"
+
+var (
+	errInvalidCursor      = errors.New("Invalid cursor")
+	errUnknownSort        = errors.New("Unknown sort")
+	errInvalidStarredOnly = errors.New("Invalid starred_only")
+	errInvalidMinScore    = errors.New("Invalid min_score")
+)
+
+// sortTerm is one ORDER BY term. typ is the SQL type of expr, which cursor
+// keys are cast back to so they compare as values rather than as text.
+type sortTerm struct {
+	expr string
+	typ  string
+	desc bool
+}
+
+func (t sortTerm) String() string {
+	if t.desc {
+		return t.expr + " DESC"
+	}
+	return t.expr
+}
+
+// messageSorts maps each sort name to its ORDER BY terms. Every ordering ends
+// with the message ID so pages are stable and cursors identify a single row.
+var messageSorts = map[string][]sortTerm{
+	"top":             {{"m.is_owner_liked", "boolean", true}, {scoreExpr, "bigint", true}, {"m.id", "bigint", false}},
+	"newest":          {{"m.created_at", "timestamptz", true}, {"m.id", "bigint", true}},
+	"oldest":          {{"m.created_at", "timestamptz", false}, {"m.id", "bigint", false}},
+	sortControversial: {{"LEAST(rc.likes, rc.dislikes)", "bigint", true}, {"rc.likes + rc.dislikes", "bigint", true}, {"m.id", "bigint", false}},
+	// random-daily depends on the day it is seeded with; see sortBy.
+	sortRandomDaily: nil,
+}
+
+const (
+	sortControversial = "controversial"
+	sortRandomDaily   = "random-daily"
+)
+
+// scoreExpr is a message's net score. Where dislikes are off it is replaced
+// with the likes alone, so dislikes the owner turned off don't show through
+// the order.
+const scoreExpr = "rc.likes - rc.dislikes"
+
+// countsDislikes reports whether dislikes are enabled on a guestbook.
+func countsDislikes(settings model.GuestbookSettings) bool {
+	return slices.Contains(allowedReactions(settings), reactionDislike)
+}
+
+// shuffleDay returns the day that seeds random-daily at t. The shuffle
+// changes at midnight UTC.
+func shuffleDay(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+// pinnedFirst puts the owner's pinned messages, in their order, ahead of
+// every sort.
+var pinnedFirst = sortTerm{expr: "COALESCE(m.pin_position, 2147483647)", typ: "integer"}
+
+// messageQueryFrom joins each message with its author, the owner's reply if
+// any (rp), and its like and dislike counts, which are available to filters
+// and orderings as rc.likes and rc.dislikes.
+const messageQueryFrom = `FROM messages m
+	JOIN users a ON a.id = m.author_id
+	LEFT JOIN message_replies rp ON rp.message_id = m.id
+	CROSS JOIN LATERAL (
+		SELECT
+			COUNT(*) FILTER (WHERE r.emoji = '` + reactionLike + `')    AS likes,
+			COUNT(*) FILTER (WHERE r.emoji = '` + reactionDislike + `') AS dislikes
+		FROM reactions r
+		WHERE r.message_id = m.id
+	) rc`
+
+type messageFilter struct {
+	starredOnly bool
+	minScore    *int
+	author      string
+}
+
+// parseSort returns the sort query parameter, falling back to the owner's
+// saved default. The controversial sort needs dislikes; a saved default that
+// can't be used falls back to top.
+func parseSort(c *gin.Context, settings model.GuestbookSettings) (string, error) {
+	sort := defaultSort
+	if settings.Sort != nil && (*settings.Sort != sortControversial || countsDislikes(settings)) {
+		sort = *settings.Sort
+	}
+	if q := c.Query("sort"); q != "" {
+		sort = q
+	}
+	if _, ok := messageSorts[sort]; !ok {
+		return "", errUnknownSort
+	}
+	if sort == sortControversial && !countsDislikes(settings) {
+		return "", errDislikesOff
+	}
+	return sort, nil
+}
+
+func parseMessageFilter(c *gin.Context) (messageFilter, error) {
+	var f messageFilter
+	if q := c.Query("starred_only"); q != "" {
+		starredOnly, err := strconv.ParseBool(q)
+		if err != nil {
+			return f, errInvalidStarredOnly
+		}
+		f.starredOnly = starredOnly
+	}
+	if q := c.Query("min_score"); q != "" {
+		minScore, err := strconv.Atoi(q)
+		if err != nil {
+			return f, errInvalidMinScore
+		}
+		f.minScore = &minScore
+	}
+	f.author = c.Query("author")
+	return f, nil
+}
+
+// messageQuery builds the queries over one guestbook's messages shared by
+// the JSON list and the rendered images, so both order and filter alike.
+type messageQuery struct {
+	where []string
+	order []sortTerm
+	args  []any
+	// noDislikes scores messages by their likes alone.
+	noDislikes bool
+}
+
+// messageShown holds for published messages that no admin has hidden and
+// whose author the owner hasn't blocked with hide_messages.
+const messageShown = `(m.status = '` + messagePublished + `' AND m.hidden_at IS NULL AND NOT EXISTS (
+		SELECT 1 FROM blocked_authors b
+		WHERE b.receiver_id = m.receiver_id AND b.author_id = m.author_id AND b.hide_messages
+	))`
+
+// newMessageQuery selects the messages on a guestbook that the viewer may
+// see: pending and hidden messages are only shown to their author. A nil
+// viewer sees shown messages only.
+func newMessageQuery(receiverID int64, viewerID *int64) *messageQuery {
+	q := &messageQuery{}
+	q.where = append(q.where, "m.receiver_id = "+q.arg(receiverID))
+	if viewerID == nil {
+		q.where = append(q.where, messageShown)
+	} else {
+		q.where = append(q.where, "("+messageShown+" OR m.author_id = "+q.arg(*viewerID)+")")
+	}
+	return q
+}
+
+// arg adds a query argument and returns its placeholder.
+func (q *messageQuery) arg(v any) string {
+	q.args = append(q.args, v)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+func (q *messageQuery) filter(f messageFilter) {
+	if f.starredOnly {
+		q.where = append(q.where, "m.is_owner_liked")
+	}
+	if f.minScore != nil {
+		q.where = append(q.where, q.score()+" >= "+q.arg(*f.minScore))
+	}
+	if f.author != "" {
+		q.where = append(q.where, "a.github_login = "+q.arg(f.author))
+	}
+}
+
+func (q *messageQuery) score() string {
+	if q.noDislikes {
+		return "rc.likes"
+	}
+	return scoreExpr
+}
+
+// orderFirst adds an ordering term that takes precedence over the sort.
+func (q *messageQuery) orderFirst(term sortTerm) {
+	q.order = append(q.order, term)
+}
+
+// sortBy orders by the named sort. day seeds random-daily, so that pages of
+// one listing keep the same shuffle.
+func (q *messageQuery) sortBy(sort, day string) {
+	q.order = append(q.order, pinnedFirst)
+	if sort == sortRandomDaily {
+		q.order = append(q.order,
+			sortTerm{"md5(m.id::text || " + q.arg(day) + ")", "text", false},
+			sortTerm{"m.id", "bigint", false},
+		)
+		return
+	}
+	for _, term := range messageSorts[sort] {
+		if term.expr == scoreExpr {
+			term.expr = q.score()
+		}
+		q.order = append(q.order, term)
+	}
+}
+
+// keyColumns selects the value of every ordering term as text, for building
+// the cursor of a row.
+func (q *messageQuery) keyColumns() []string {
+	columns := make([]string, len(q.order))
+	for i, term := range q.order {
+		columns[i] = "(" + term.expr + ")::text"
+	}
+	return columns
+}
+
+// after restricts the query to the rows ordered after the row whose
+// keyColumns were keys. It must be called once the ordering is complete.
+func (q *messageQuery) after(keys []string) error {
+	if len(keys) != len(q.order) {
+		return errInvalidCursor
+	}
+
+	placeholders := make([]string, len(keys))
+	for i, key := range keys {
+		placeholders[i] = q.arg(key) + "::" + q.order[i].typ
+	}
+
+	// Terms may sort in different directions, so compare term by term instead
+	// of with a row comparison.
+	var alternatives []string
+	for i, term := range q.order {
+		var conds []string
+		for j := range i {
+			conds = append(conds, fmt.Sprintf("(%s) = %s", q.order[j].expr, placeholders[j]))
+		}
+		op := ">"
+		if term.desc {
+			op = "<"
+		}
+		conds = append(conds, fmt.Sprintf("(%s) %s %s", term.expr, op, placeholders[i]))
+		alternatives = append(alternatives, "("+strings.Join(conds, " AND ")+")")
+	}
+	q.where = append(q.where, "("+strings.Join(alternatives, " OR ")+")")
+	return nil
+}
+
+// countSQL counts the matching messages and how many of them are starred.
+func (q *messageQuery) countSQL() string {
+	return fmt.Sprintf("SELECT COUNT(*), COUNT(*) FILTER (WHERE m.is_owner_liked) %s WHERE %s",
+		messageQueryFrom, strings.Join(q.where, " AND "))
+}
+
+// selectSQL selects columns from the matching messages in sort order. A
+// negative limit selects them all.
+func (q *messageQuery) selectSQL(limit, offset int, columns ...string) string {
+	order := make([]string, len(q.order))
+	for i, term := range q.order {
+		order[i] = term.String()
+	}
+	query := fmt.Sprintf("SELECT %s %s WHERE %s ORDER BY %s",
+		strings.Join(columns, ", "), messageQueryFrom, strings.Join(q.where, " AND "), strings.Join(order, ", "))
+	if limit >= 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	if offset > 0 {
+		query += fmt.Sprintf(" OFFSET %d", offset)
+	}
+	return query
+}
+
+// messageCursor marks where a page of the message list ended. Cursors are
+// only valid for the sort they were issued for. Day is the shuffle day of
+// random-daily cursors.
+type messageCursor struct {
+	Sort string   `json:"s"`
+	Day  string   `json:"d,omitempty"`
+	Keys []string `json:"k"`
+}
+
+func (mc messageCursor) encode() string {
+	data, _ := json.Marshal(mc)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s, sort string) (messageCursor, error) {
+	var mc messageCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return mc, errInvalidCursor
+	}
+	if err := json.Unmarshal(data, &mc); err != nil || mc.Sort != sort {
+		return mc, errInvalidCursor
+	}
+	if sort == sortRandomDaily {
+		if _, err := time.Parse(time.DateOnly, mc.Day); err != nil {
+			return mc, errInvalidCursor
+		}
+	}
+	return mc, nil
+}
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/in-jun/github-profile-guestbook/internal/db"
+)
+
+func TestMessageQueryAfterCastsKeys(t *testing.T) {
+	q := newMessageQuery(1, nil)
+	q.sortBy("top", "2024-01-01")
+	if err := q.after([]string{"2147483647", "false", "10", "7"}); err != nil {
+		t.Fatal(err)
+	}
+	where := q.where[len(q.where)-1]
+	for _, want := range []string{"$2::integer", "$3::boolean", "$4::bigint", "$5::bigint"} {
+		if !strings.Contains(where, want) {
+			t.Errorf("cursor condition %q lacks %q", where, want)
+		}
+	}
+
+	if err := q.after([]string{"1"}); err != errInvalidCursor {
+		t.Errorf("after with too few keys: got %v, want %v", err, errInvalidCursor)
+	}
+}
+
+func TestDecodeCursorDay(t *testing.T) {
+	tests := []struct {
+		cursor messageCursor
+		sort   string
+		ok     bool
+	}{
+		{messageCursor{Sort: sortRandomDaily, Day: "2024-01-01", Keys: []string{"1"}}, sortRandomDaily, true},
+		{messageCursor{Sort: sortRandomDaily, Keys: []string{"1"}}, sortRandomDaily, false},
+		{messageCursor{Sort: sortRandomDaily, Day: "yesterday", Keys: []string{"1"}}, sortRandomDaily, false},
+		{messageCursor{Sort: "newest", Keys: []string{"1"}}, "newest", true},
+		{messageCursor{Sort: "newest", Keys: []string{"1"}}, "oldest", false},
+	}
+	for _, tt := range tests {
+		mc, err := decodeCursor(tt.cursor.encode(), tt.sort)
+		if (err == nil) != tt.ok {
+			t.Errorf("decodeCursor(%+v, %q) error = %v, want ok %v", tt.cursor, tt.sort, err, tt.ok)
+		}
+		if err == nil && mc.Day != tt.cursor.Day {
+			t.Errorf("decodeCursor(%+v) day = %q", tt.cursor, mc.Day)
+		}
+	}
+}
+
+// openTestDB connects to the database in TEST_DATABASE_URL and migrates it.
+// The tests add their own users and remove them again.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	password, _ := u.User.Password()
+	if err := db.RunMigrations(u.Hostname(), u.Port(), u.User.Username(), password, strings.TrimPrefix(u.Path, "/")); err != nil {
+		t.Fatal(err)
+	}
+	database, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func TestMessageQueryPaging(t *testing.T) {
+	database := openTestDB(t)
+
+	const prefix = "keyset-test-"
+	cleanup := func() {
+		if _, err := database.Exec("DELETE FROM users WHERE github_login LIKE $1", prefix+"%"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+
+	addUser := func(name string) int64 {
+		var id int64
+		err := database.QueryRow(
+			"INSERT INTO users (github_id, github_login) VALUES ((SELECT COALESCE(MAX(github_id), 0) + 1 FROM users), $1) RETURNING id",
+			prefix+name,
+		).Scan(&id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	receiverID := addUser("receiver")
+	voters := make([]int64, 12)
+	for i := range voters {
+		voters[i] = addUser(fmt.Sprintf("voter-%d", i))
+	}
+	// Scores mix one and two digits, so comparing them as text would put
+	// "10" before "9".
+	votes := []struct{ likes, dislikes int }{
+		{9, 0}, {10, 0}, {2, 0}, {11, 1}, {1, 0}, {12, 2}, {10, 10}, {9, 9}, {3, 0}, {12, 0},
+	}
+	for i, v := range votes {
+		authorID := addUser(fmt.Sprintf("author-%d", i))
+		var messageID int64
+		err := database.QueryRow(
+			"INSERT INTO messages (receiver_id, author_id, content) VALUES ($1, $2, 'hi') RETURNING id",
+			receiverID, authorID,
+		).Scan(&messageID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := range v.likes {
+			if _, err := database.Exec("INSERT INTO reactions (message_id, user_id, emoji) VALUES ($1, $2, $3)", messageID, voters[j], reactionLike); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for j := range v.dislikes {
+			voter := voters[len(voters)-1-j]
+			if _, err := database.Exec("INSERT INTO reactions (message_id, user_id, emoji) VALUES ($1, $2, $3)", messageID, voter, reactionDislike); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	ids := func(query string, args []any) []int64 {
+		rows, err := database.Query(query, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		var ids []int64
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, id)
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		sort     string
+		pageSize int
+	}{
+		{"top", 1},
+		{"top", 3},
+		{"controversial", 2},
+		{"newest", 3},
+		{"oldest", 4},
+		{"random-daily", 3},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.sort, tt.pageSize), func(t *testing.T) {
+			all := newMessageQuery(receiverID, nil)
+			all.sortBy(tt.sort, "2024-01-01")
+			want := ids(all.selectSQL(-1, 0, "m.id"), all.args)
+			if len(want) != len(votes) {
+				t.Fatalf("got %d messages, want %d", len(want), len(votes))
+			}
+
+			var got []int64
+			var keys []string
+			for page := 0; page <= len(votes); page++ {
+				q := newMessageQuery(receiverID, nil)
+				q.sortBy(tt.sort, "2024-01-01")
+				if keys != nil {
+					if err := q.after(keys); err != nil {
+						t.Fatal(err)
+					}
+				}
+				rows, err := database.Query(q.selectSQL(tt.pageSize, 0, append([]string{"m.id"}, q.keyColumns()...)...), q.args...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				n := 0
+				for rows.Next() {
+					var id int64
+					keys = make([]string, len(q.order))
+					dest := []any{&id}
+					for i := range keys {
+						dest = append(dest, &keys[i])
+					}
+					if err := rows.Scan(dest...); err != nil {
+						t.Fatal(err)
+					}
+					got = append(got, id)
+					n++
+				}
+				rows.Close()
+				if n < tt.pageSize {
+					break
+				}
+			}
+
+			if !slices.Equal(got, want) {
+				t.Errorf("paged order %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestMessageQueryWithoutDislikes(t *testing.T) {
+	q := newMessageQuery(1, nil)
+	q.noDislikes = true
+	minScore := 3
+	q.filter(messageFilter{minScore: &minScore})
+	q.sortBy("top", "2024-01-01")
+	query := q.selectSQL(10, 0, "m.id")
+	if where := query[strings.Index(query, " WHERE "):]; strings.Contains(where, "rc.dislikes") {
+		t.Errorf("query ranks or filters by dislikes: %s", where)
+	}
+
+	off := "no_dislikes"
+	settings := defaultSettings()
+	settings.ReactionsMode = &off
+	if countsDislikes(settings) {
+		t.Error("countsDislikes with no_dislikes = true")
+	}
+	if !countsDislikes(defaultSettings()) {
+		t.Error("countsDislikes by default = false")
+	}
+}
@@ -0,0 +1,351 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/in-jun/github-profile-guestbook/internal/model"
+)
+
+// Likes and dislikes are stored as these reactions. A user can have only
+// one of the two on a message.
+const (
+	reactionLike    = "👍"
+	reactionDislike = "👎"
+)
+
+const maxReactionLength = 32
+
+// Reaction modes an owner can choose for visitors.
+const (
+	reactionsAll        = "all"         // every reaction the guestbook accepts
+	reactionsNoDislikes = "no_dislikes" // the same without dislikes
+	reactionsNone       = "none"        // no reactions at all
+)
+
+var reactionsModes = map[string]bool{
+	reactionsAll:        true,
+	reactionsNoDislikes: true,
+	reactionsNone:       true,
+}
+
+var (
+	errUnknownReaction      = errors.New("Unknown reaction")
+	errUnknownReactionsMode = errors.New("Unknown reactions mode")
+	errReactionNotAllowed   = errors.New("Reaction not allowed on this guestbook")
+	errDislikesOff          = errors.New("Dislikes are turned off on this guestbook")
+	errReactionsOff         = errors.New("Reactions are turned off on this guestbook")
+)
+
+// reactionPalette is every reaction the site offers, in display order.
+// Owners may narrow it down for their guestbook.
+var reactionPalette = []string{reactionLike, reactionDislike, "❤️", "🎉", "😂", "👀"}
+
+// SetReactionPalette replaces the default palette. An empty list keeps the
+// default.
+func SetReactionPalette(emojis []string) error {
+	if len(emojis) == 0 {
+		return nil
+	}
+	var palette []string
+	for _, e := range emojis {
+		if len(e) > maxReactionLength || strings.ContainsFunc(e, func(r rune) bool {
+			return r == '/' || unicode.IsSpace(r) || unicode.IsControl(r)
+		}) {
+			return fmt.Errorf("invalid reaction %q", e)
+		}
+		if !slices.Contains(palette, e) {
+			palette = append(palette, e)
+		}
+	}
+	reactionPalette = palette
+	return nil
+}
+
+// matchReaction returns the palette entry for e. Variation selectors are
+// ignored, so "❤" matches "❤️".
+func matchReaction(e string) (string, bool) {
+	bare := strings.ReplaceAll(e, "\uFE0F", "")
+	for _, p := range reactionPalette {
+		if strings.ReplaceAll(p, "\uFE0F", "") == bare {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+func reactionsMode(settings model.GuestbookSettings) string {
+	if settings.ReactionsMode == nil {
+		return reactionsAll
+	}
+	return *settings.ReactionsMode
+}
+
+// allowedReactions returns the reactions enabled on a guestbook, in palette
+// order: the owner's chosen subset, less what their reactions mode turns
+// off.
+func allowedReactions(settings model.GuestbookSettings) []string {
+	mode := reactionsMode(settings)
+	allowed := make([]string, 0, len(reactionPalette))
+	for _, p := range reactionPalette {
+		if settings.Reactions != nil && !slices.Contains(settings.Reactions, p) {
+			continue
+		}
+		if mode == reactionsNone || (mode == reactionsNoDislikes && p == reactionDislike) {
+			continue
+		}
+		allowed = append(allowed, p)
+	}
+	return allowed
+}
+
+// reactionCountsColumn selects a message's reaction counts as a JSON array
+// of model.ReactionCount, most used first. viewer is the placeholder of the
+// viewer's user ID, which may be NULL.
+func reactionCountsColumn(viewer string) string {
+	return `(SELECT COALESCE(json_agg(json_build_object('emoji', x.emoji, 'count', x.count, 'reacted', x.reacted)
+			ORDER BY x.count DESC, x.first), '[]')
+		FROM (
+			SELECT emoji, COUNT(*) AS count, COALESCE(BOOL_OR(user_id = ` + viewer + `), FALSE) AS reacted, MIN(created_at) AS first
+			FROM reactions WHERE message_id = m.id GROUP BY emoji
+		) x)`
+}
+
+type ReactionHandler struct {
+	db    *sql.DB
+	cache *RenderCache
+}
+
+func NewReactionHandler(db *sql.DB, cache *RenderCache) *ReactionHandler {
+	return &ReactionHandler{db: db, cache: cache}
+}
+
+// reactionTarget looks up a message the user is about to react to and
+// writes an error response unless they may.
+func reactionTarget(c *gin.Context, db *sql.DB, messageID, userID int64, failMsg, ownMsg string) (int64, bool) {
+	var authorID, receiverID int64
+	err := db.QueryRow("SELECT author_id, receiver_id FROM messages WHERE id = $1 AND status = '"+messagePublished+"' AND hidden_at IS NULL", messageID).Scan(&authorID, &receiverID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "Message not found")})
+		return 0, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, failMsg)})
+		return 0, false
+	}
+
+	if authorID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, ownMsg)})
+		return 0, false
+	}
+	if !requireNotBlocked(c, db, receiverID, userID, failMsg) {
+		return 0, false
+	}
+	return receiverID, true
+}
+
+// requireReactionAllowed writes an error response unless emoji is enabled
+// on the guestbook.
+func requireReactionAllowed(c *gin.Context, db *sql.DB, receiverID int64, emoji, failMsg string) bool {
+	settings, err := loadSettings(db, receiverID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, failMsg)})
+		return false
+	}
+	if err := checkReaction(settings, emoji); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+		return false
+	}
+	return true
+}
+
+// checkReaction tells why emoji can't be used on a guestbook, or returns
+// nil if it can.
+func checkReaction(settings model.GuestbookSettings, emoji string) error {
+	switch mode := reactionsMode(settings); {
+	case mode == reactionsNone:
+		return errReactionsOff
+	case mode == reactionsNoDislikes && emoji == reactionDislike:
+		return errDislikesOff
+	case !slices.Contains(allowedReactions(settings), emoji):
+		return errReactionNotAllowed
+	}
+	return nil
+}
+
+// removeReaction deletes the user's reaction and reports the guestbook it
+// was on, if there was one.
+func removeReaction(db *sql.DB, messageID, userID int64, emoji string) (receiverID int64, removed bool, err error) {
+	err = db.QueryRow(
+		`DELETE FROM reactions r USING messages m
+		 WHERE m.id = r.message_id AND r.message_id = $1 AND r.user_id = $2 AND r.emoji = $3
+		 RETURNING m.receiver_id`,
+		messageID, userID, emoji,
+	).Scan(&receiverID)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	return receiverID, err == nil, err
+}
+
+// Add reacts to a message with an emoji. Reacting twice is a no-op, and
+// liking a disliked message, or the other way round, replaces the reaction.
+func (h *ReactionHandler) Add(c *gin.Context) {
+	messageID, ok := parseMessageID(c)
+	if !ok {
+		return
+	}
+	userID, ok := requireAuth(c)
+	if !ok {
+		return
+	}
+	emoji, ok := matchReaction(c.Param("emoji"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, errUnknownReaction.Error())})
+		return
+	}
+
+	receiverID, ok := reactionTarget(c, h.db, messageID, userID, "Failed to add reaction", "You can't react to your own message")
+	if !ok {
+		return
+	}
+	if !requireReactionAllowed(c, h.db, receiverID, emoji, "Failed to add reaction") {
+		return
+	}
+
+	var err error
+	if value := emojiVote(emoji); value != 0 {
+		_, _, err = applyVote(h.db, messageID, userID, value, nil)
+	} else {
+		_, err = h.db.Exec(
+			"INSERT INTO reactions (message_id, user_id, emoji) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
+			messageID, userID, emoji,
+		)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to add reaction")})
+		return
+	}
+	h.cache.Invalidate(receiverID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reaction added"})
+}
+
+// Remove takes back a reaction. Removing one that isn't there is a no-op.
+// Reactions that were dropped from the palette can still be removed.
+func (h *ReactionHandler) Remove(c *gin.Context) {
+	messageID, ok := parseMessageID(c)
+	if !ok {
+		return
+	}
+	userID, ok := requireAuth(c)
+	if !ok {
+		return
+	}
+	emoji := c.Param("emoji")
+	if e, ok := matchReaction(emoji); ok {
+		emoji = e
+	}
+
+	receiverID, removed, err := removeReaction(h.db, messageID, userID, emoji)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to remove reaction")})
+		return
+	}
+	if removed {
+		h.cache.Invalidate(receiverID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reaction removed"})
+}
+
+// List returns who reacted to a message, newest first, paged with limit and
+// offset; emoji narrows it to one reaction. Reactors the owner blocked are
+// left out, and when the owner hides dislikers, only they see who disliked.
+func (h *ReactionHandler) List(c *gin.Context) {
+	messageID, ok := parseMessageID(c)
+	if !ok {
+		return
+	}
+	limit, offset, err := parsePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+
+	var viewerID *int64
+	if uid, ok := c.Get("user_id"); ok {
+		id := uid.(int64)
+		viewerID = &id
+	}
+
+	var receiverID int64
+	err = h.db.QueryRow(
+		"SELECT m.receiver_id FROM messages m WHERE m.id = $1 AND ("+messageShown+" OR m.author_id = $2)",
+		messageID, viewerID,
+	).Scan(&receiverID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "Message not found")})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get reactions")})
+		return
+	}
+
+	settings, err := loadSettings(h.db, receiverID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get reactions")})
+		return
+	}
+	hideDislikers := settings.HideDislikers && (viewerID == nil || *viewerID != receiverID)
+
+	args := []any{messageID, receiverID, reactionDislike, hideDislikers, limit, offset}
+	emojiFilter := ""
+	if q := c.Query("emoji"); q != "" {
+		if e, ok := matchReaction(q); ok {
+			q = e
+		}
+		args = append(args, q)
+		emojiFilter = " AND r.emoji = $7"
+	}
+
+	rows, err := h.db.Query(
+		`SELECT CASE WHEN r.emoji = $3 AND $4 THEN NULL ELSE u.github_login END, r.emoji, r.created_at
+		 FROM reactions r
+		 JOIN users u ON u.id = r.user_id
+		 WHERE r.message_id = $1 AND NOT EXISTS (
+			SELECT 1 FROM blocked_authors b WHERE b.receiver_id = $2 AND b.author_id = r.user_id
+		 )`+emojiFilter+`
+		 ORDER BY r.created_at DESC, r.user_id
+		 LIMIT $5 OFFSET $6`,
+		args...,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get reactions")})
+		return
+	}
+	defer rows.Close()
+
+	reactors := make([]model.Reactor, 0)
+	for rows.Next() {
+		var r model.Reactor
+		if err := rows.Scan(&r.User, &r.Emoji, &r.CreatedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get reactions")})
+			return
+		}
+		reactors = append(reactors, r)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get reactions")})
+		return
+	}
+
+	c.JSON(http.StatusOK, reactors)
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/in-jun/github-profile-guestbook/internal/model"
+	"github.com/lib/pq"
+)
+
+const (
+	maxRemovalReasonLength = 500
+	maxBulkRemovals        = 100
+)
+
+// removeMessages marks messages on the owner's guestbook as removed and
+// records each removal, with its content, in the audit log.
+const removeMessages = `WITH removed AS (
+		UPDATE messages SET status = '` + messageRemoved + `', pin_position = NULL
+		WHERE receiver_id = $1 AND id = ANY($2) AND status <> '` + messageRemoved + `'
+		RETURNING id, author_id, content
+	)
+	INSERT INTO message_removals (message_id, receiver_id, author_id, content, reason)
+	SELECT id, $1, author_id, content, $3 FROM removed`
+
+// RemovalHandler lets owners take down messages left on their guestbook.
+// Removed messages stay visible to their author, marked as removed.
+type RemovalHandler struct {
+	db    *sql.DB
+	cache *RenderCache
+}
+
+func NewRemovalHandler(db *sql.DB, cache *RenderCache) *RemovalHandler {
+	return &RemovalHandler{db: db, cache: cache}
+}
+
+// bindRemovalReason reads the optional reason from the request body, which
+// may be empty.
+func bindRemovalReason(c *gin.Context, req any, reason *string) (*string, bool) {
+	if err := c.ShouldBindJSON(req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+		return nil, false
+	}
+	trimmed := strings.TrimSpace(*reason)
+	if trimmed == "" {
+		return nil, true
+	}
+	if utf8.RuneCountInString(trimmed) > maxRemovalReasonLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "Removal reason is too long")})
+		return nil, false
+	}
+	return &trimmed, true
+}
+
+func (h *RemovalHandler) Delete(c *gin.Context) {
+	messageID, ok := parseMessageID(c)
+	if !ok {
+		return
+	}
+	userID, ok := requireAuth(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	reason, ok := bindRemovalReason(c, &req, &req.Reason)
+	if !ok {
+		return
+	}
+
+	result, err := h.db.Exec(removeMessages, userID, pq.Array([]int64{messageID}), reason)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to delete message")})
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "Message not found")})
+		return
+	}
+	h.cache.Invalidate(userID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message deleted"})
+}
+
+// BulkDelete removes several messages at once with a shared reason. IDs
+// that aren't on the caller's guestbook, or are already removed, are
+// skipped; the response says how many were removed.
+func (h *RemovalHandler) BulkDelete(c *gin.Context) {
+	userID, ok := requireAuth(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		MessageIDs []int64 `json:"message_ids"`
+		Reason     string  `json:"reason"`
+	}
+	reason, ok := bindRemovalReason(c, &req, &req.Reason)
+	if !ok {
+		return
+	}
+	if len(req.MessageIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "Message IDs not provided")})
+		return
+	}
+	if len(req.MessageIDs) > maxBulkRemovals {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "Too many messages")})
+		return
+	}
+
+	result, err := h.db.Exec(removeMessages, userID, pq.Array(req.MessageIDs), reason)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to delete messages")})
+		return
+	}
+	n, _ := result.RowsAffected()
+	if n > 0 {
+		h.cache.Invalidate(userID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Messages deleted", "deleted": n})
+}
+
+// List returns the caller's removal log, newest first, paged with limit
+// and offset.
+func (h *RemovalHandler) List(c *gin.Context) {
+	userID, ok := requireAuth(c)
+	if !ok {
+		return
+	}
+
+	limit, offset, err := parsePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+
+	rows, err := h.db.Query(
+		`SELECT r.id, r.message_id, u.github_login, r.content, r.reason, r.created_at
+		 FROM message_removals r
+		 JOIN users u ON u.id = r.author_id
+		 WHERE r.receiver_id = $1
+		 ORDER BY r.created_at DESC, r.id DESC
+		 LIMIT $2 OFFSET $3`,
+		userID, limit, offset,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get removed messages")})
+		return
+	}
+	defer rows.Close()
+
+	removals := make([]model.MessageRemoval, 0)
+	for rows.Next() {
+		var r model.MessageRemoval
+		if err := rows.Scan(&r.ID, &r.MessageID, &r.Author, &r.Content, &r.Reason, &r.CreatedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get removed messages")})
+			return
+		}
+		removals = append(removals, r)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get removed messages")})
+		return
+	}
+
+	c.JSON(http.StatusOK, removals)
+}
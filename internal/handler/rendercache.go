@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	renderCacheMaxEntries = 4096
+	// renderPendingTTL is used for renders drawn while some avatars were still
+	// being fetched, so the finished version replaces them quickly.
+	renderPendingTTL = 15 * time.Second
+)
+
+// renderParams are the query parameters that change how a guestbook is drawn.
+// Anything else, such as cache-busting parameters, shares a cached render.
+var renderParams = []string{
+	"layout", "theme", "bg", "text", "border", "accent", "lang", "mode", "interval", "scheme",
+	"limit", "offset", "sort", "starred_only", "min_score", "author", "timestamps",
+}
+
+type renderedImage struct {
+	body     []byte
+	etag     string
+	modified time.Time
+}
+
+func newRenderedImage(body []byte) *renderedImage {
+	sum := sha256.Sum256(body)
+	return &renderedImage{
+		body:     body,
+		etag:     `"` + hex.EncodeToString(sum[:16]) + `"`,
+		modified: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+type renderEntry struct {
+	img       *renderedImage
+	expiresAt time.Time
+}
+
+type renderCall struct {
+	done chan struct{}
+	img  *renderedImage
+	err  error
+}
+
+// RenderCache holds rendered guestbook images per receiver and rendering
+// options. Handlers that change a guestbook call Invalidate; every change
+// bumps the receiver's generation so renders started before it are never
+// stored.
+type RenderCache struct {
+	mu          sync.Mutex
+	entries     map[int64]map[string]*renderEntry
+	generations map[int64]uint64
+	calls       map[string]*renderCall
+	size        int
+	ttl         time.Duration
+}
+
+func NewRenderCache(ttl time.Duration) *RenderCache {
+	rc := &RenderCache{
+		entries:     make(map[int64]map[string]*renderEntry),
+		generations: make(map[int64]uint64),
+		calls:       make(map[string]*renderCall),
+		ttl:         ttl,
+	}
+	go rc.cleanup()
+	return rc
+}
+
+// renderKey identifies a rendering of a guestbook by output format and the
+// query parameters in renderParams.
+func renderKey(c *gin.Context, format string) string {
+	var b strings.Builder
+	b.WriteString(format)
+	for _, name := range renderParams {
+		b.WriteByte('&')
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(c.Query(name))
+	}
+	return b.String()
+}
+
+// get returns the cached image for key, or calls render to produce it.
+// Concurrent misses for the same key wait for a single render. render returns
+// how long its result may be cached; zero means not at all.
+func (rc *RenderCache) get(receiverID int64, key string, render func() ([]byte, time.Duration, error)) (*renderedImage, error) {
+	rc.mu.Lock()
+	if entry, ok := rc.entries[receiverID][key]; ok && time.Now().Before(entry.expiresAt) {
+		rc.mu.Unlock()
+		return entry.img, nil
+	}
+
+	generation := rc.generations[receiverID]
+	callKey := fmt.Sprintf("%d:%d:%s", receiverID, generation, key)
+	if call, ok := rc.calls[callKey]; ok {
+		rc.mu.Unlock()
+		<-call.done
+		return call.img, call.err
+	}
+
+	call := &renderCall{done: make(chan struct{})}
+	rc.calls[callKey] = call
+	rc.mu.Unlock()
+
+	body, ttl, err := render()
+	if err == nil {
+		call.img = newRenderedImage(body)
+	}
+	call.err = err
+
+	rc.mu.Lock()
+	delete(rc.calls, callKey)
+	if err == nil && ttl > 0 && rc.generations[receiverID] == generation {
+		rc.store(receiverID, key, &renderEntry{img: call.img, expiresAt: time.Now().Add(min(ttl, rc.ttl))})
+	}
+	rc.mu.Unlock()
+	close(call.done)
+
+	return call.img, call.err
+}
+
+// store must be called with rc.mu held.
+func (rc *RenderCache) store(receiverID int64, key string, entry *renderEntry) {
+	// Evict first: evictOne may drop this receiver's own renders.
+	_, exists := rc.entries[receiverID][key]
+	if !exists && rc.size >= renderCacheMaxEntries {
+		rc.evictOne()
+	}
+	keys, ok := rc.entries[receiverID]
+	if !ok {
+		keys = make(map[string]*renderEntry)
+		rc.entries[receiverID] = keys
+	}
+	if !exists {
+		rc.size++
+	}
+	keys[key] = entry
+}
+
+// evictOne drops an arbitrary receiver's renders to make room. Map iteration
+// order is random, so no guestbook can keep others out of the cache.
+func (rc *RenderCache) evictOne() {
+	for id, keys := range rc.entries {
+		rc.size -= len(keys)
+		delete(rc.entries, id)
+		return
+	}
+}
+
+// Invalidate drops every cached render of a receiver's guestbook.
+func (rc *RenderCache) Invalidate(receiverID int64) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.generations[receiverID]++
+	rc.size -= len(rc.entries[receiverID])
+	delete(rc.entries, receiverID)
+}
+
+func (rc *RenderCache) cleanup() {
+	ticker := time.NewTicker(10 * time.Minute)
+	for range ticker.C {
+		rc.mu.Lock()
+		now := time.Now()
+		for id, keys := range rc.entries {
+			for key, entry := range keys {
+				if now.After(entry.expiresAt) {
+					delete(keys, key)
+					rc.size--
+				}
+			}
+			if len(keys) == 0 {
+				delete(rc.entries, id)
+			}
+		}
+		rc.mu.Unlock()
+	}
+}
+
+// writeRendered sends a rendered image with validators, answering
+// If-None-Match and If-Modified-Since with 304 Not Modified.
+func writeRendered(c *gin.Context, contentType string, img *renderedImage) {
+	c.Writer.Header().Set("Content-Type", contentType)
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("ETag", img.etag)
+	http.ServeContent(c.Writer, c.Request, "", img.modified, bytes.NewReader(img.body))
+}
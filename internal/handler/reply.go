@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/in-jun/github-profile-guestbook/internal/filter"
+)
+
+// ReplyHandler manages the guestbook owner's public replies to messages. A
+// message has at most one reply.
+type ReplyHandler struct {
+	db    *sql.DB
+	cache *RenderCache
+}
+
+func NewReplyHandler(db *sql.DB, cache *RenderCache) *ReplyHandler {
+	return &ReplyHandler{db: db, cache: cache}
+}
+
+// replyFilters only applies the basic checks, since owners reply on their
+// own guestbook.
+var replyFilters = filter.Basic(maxReplyLength)
+
+// bindReply reads and validates the reply content from the request body.
+func bindReply(c *gin.Context) (string, bool) {
+	var req struct {
+		Content string `json:"content"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+		return "", false
+	}
+
+	content, err := replyFilters.Filter(c.Request.Context(), filter.Message{Content: req.Content})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+		return "", false
+	}
+	return content, true
+}
+
+// requireOwner checks that the message is on userID's guestbook. failMsg is
+// reported if the lookup fails.
+func (h *ReplyHandler) requireOwner(c *gin.Context, messageID, userID int64, failMsg string) bool {
+	var receiverID int64
+	err := h.db.QueryRow("SELECT receiver_id FROM messages WHERE id = $1", messageID).Scan(&receiverID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "Message not found")})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, failMsg)})
+		return false
+	}
+	if receiverID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": tr(c, "Only the guestbook owner can reply to messages")})
+		return false
+	}
+	return true
+}
+
+func (h *ReplyHandler) Create(c *gin.Context) {
+	messageID, ok := parseMessageID(c)
+	if !ok {
+		return
+	}
+	userID, ok := requireAuth(c)
+	if !ok {
+		return
+	}
+	content, ok := bindReply(c)
+	if !ok {
+		return
+	}
+	if !h.requireOwner(c, messageID, userID, "Failed to create reply") {
+		return
+	}
+
+	_, err := h.db.Exec("INSERT INTO message_replies (message_id, content) VALUES ($1, $2)", messageID, content)
+	if err != nil {
+		if isUniqueViolation(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "Message already has a reply")})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to create reply")})
+		}
+		return
+	}
+	h.cache.Invalidate(userID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reply created"})
+}
+
+func (h *ReplyHandler) Edit(c *gin.Context) {
+	messageID, ok := parseMessageID(c)
+	if !ok {
+		return
+	}
+	userID, ok := requireAuth(c)
+	if !ok {
+		return
+	}
+	content, ok := bindReply(c)
+	if !ok {
+		return
+	}
+	if !h.requireOwner(c, messageID, userID, "Failed to edit reply") {
+		return
+	}
+
+	result, err := h.db.Exec(
+		"UPDATE message_replies SET content = $2, updated_at = NOW() WHERE message_id = $1",
+		messageID, content,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to edit reply")})
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "Reply not found")})
+		return
+	}
+	h.cache.Invalidate(userID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reply updated"})
+}
+
+func (h *ReplyHandler) Delete(c *gin.Context) {
+	messageID, ok := parseMessageID(c)
+	if !ok {
+		return
+	}
+	userID, ok := requireAuth(c)
+	if !ok {
+		return
+	}
+	if !h.requireOwner(c, messageID, userID, "Failed to delete reply") {
+		return
+	}
+
+	result, err := h.db.Exec("DELETE FROM message_replies WHERE message_id = $1", messageID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to delete reply")})
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "Reply not found")})
+		return
+	}
+	h.cache.Invalidate(userID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reply deleted"})
+}
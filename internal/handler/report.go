@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxReportReasonLength = 500
+
+// ReportHandler lets anyone signed in flag a message for the admins.
+type ReportHandler struct {
+	db *sql.DB
+}
+
+func NewReportHandler(db *sql.DB) *ReportHandler {
+	return &ReportHandler{db: db}
+}
+
+// Create files a report. The message content is copied into the report so
+// admins see what was reported even if it is edited or deleted later.
+func (h *ReportHandler) Create(c *gin.Context) {
+	messageID, ok := parseMessageID(c)
+	if !ok {
+		return
+	}
+	userID, ok := requireAuth(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "Reason not provided")})
+		return
+	}
+	if utf8.RuneCountInString(reason) > maxReportReasonLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "Reason is too long")})
+		return
+	}
+
+	var receiverID, authorID int64
+	var content string
+	err := h.db.QueryRow(
+		"SELECT m.receiver_id, m.author_id, m.content FROM messages m WHERE m.id = $1 AND "+messageShown,
+		messageID,
+	).Scan(&receiverID, &authorID, &content)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": tr(c, "Message not found")})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to report message")})
+		return
+	}
+	if authorID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "You can't report your own message")})
+		return
+	}
+
+	_, err = h.db.Exec(
+		`INSERT INTO reports (message_id, receiver_id, author_id, reporter_id, reason, content)
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		messageID, receiverID, authorID, userID, reason, content,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, "You already reported this message")})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to report message")})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message reported"})
+}
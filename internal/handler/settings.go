@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/in-jun/github-profile-guestbook/internal/filter"
+	"github.com/in-jun/github-profile-guestbook/internal/i18n"
+	"github.com/in-jun/github-profile-guestbook/internal/model"
+	"github.com/lib/pq"
+)
+
+const maxBlockedWords = 200
+
+var errTooManyBlockedWords = errors.New("Too many blocked words")
+
+type SettingsHandler struct {
+	db    *sql.DB
+	cache *RenderCache
+}
+
+func NewSettingsHandler(db *sql.DB, cache *RenderCache) *SettingsHandler {
+	return &SettingsHandler{db: db, cache: cache}
+}
+
+func defaultSettings() model.GuestbookSettings {
+	return model.GuestbookSettings{Theme: defaultThemeName}
+}
+
+func loadSettings(db *sql.DB, userID int64) (model.GuestbookSettings, error) {
+	s := defaultSettings()
+	err := db.QueryRow(
+		`SELECT theme, bg_color, text_color, border_color, accent_color, max_messages, language, sort, moderation, blocked_words, reactions,
+		        reactions_mode, hide_dislikers
+		 FROM guestbook_settings WHERE user_id = $1`,
+		userID,
+	).Scan(&s.Theme, &s.Bg, &s.Text, &s.Border, &s.Accent, &s.MaxMessages, &s.Language, &s.Sort, &s.Moderation,
+		pq.Array(&s.BlockedWords), pq.Array(&s.Reactions), &s.ReactionsMode, &s.HideDislikers)
+	if err == sql.ErrNoRows {
+		return defaultSettings(), nil
+	}
+	return s, err
+}
+
+func saveSettings(db *sql.DB, userID int64, s model.GuestbookSettings) error {
+	_, err := db.Exec(
+		`INSERT INTO guestbook_settings (user_id, theme, bg_color, text_color, border_color, accent_color, max_messages, language, sort, moderation, blocked_words, reactions,
+		                                 reactions_mode, hide_dislikers)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		 ON CONFLICT (user_id) DO UPDATE SET
+			theme          = EXCLUDED.theme,
+			bg_color       = EXCLUDED.bg_color,
+			text_color     = EXCLUDED.text_color,
+			border_color   = EXCLUDED.border_color,
+			accent_color   = EXCLUDED.accent_color,
+			max_messages   = EXCLUDED.max_messages,
+			language       = EXCLUDED.language,
+			sort           = EXCLUDED.sort,
+			moderation     = EXCLUDED.moderation,
+			blocked_words  = EXCLUDED.blocked_words,
+			reactions      = EXCLUDED.reactions,
+			reactions_mode = EXCLUDED.reactions_mode,
+			hide_dislikers = EXCLUDED.hide_dislikers,
+			updated_at     = NOW()`,
+		userID, s.Theme, s.Bg, s.Text, s.Border, s.Accent, s.MaxMessages, s.Language, s.Sort, s.Moderation,
+		pq.Array(s.BlockedWords), pq.Array(s.Reactions), s.ReactionsMode, s.HideDislikers,
+	)
+	return err
+}
+
+func (h *SettingsHandler) Get(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "Unauthorized")})
+		return
+	}
+
+	settings, err := loadSettings(h.db, userID.(int64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get settings")})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *SettingsHandler) Update(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": tr(c, "Unauthorized")})
+		return
+	}
+	ownerID := userID.(int64)
+
+	// Omitted fields keep their saved value; an empty string or a zero limit
+	// clears the override.
+	var req struct {
+		Theme       *string `json:"theme"`
+		Bg          *string `json:"bg"`
+		Text        *string `json:"text"`
+		Border      *string `json:"border"`
+		Accent      *string `json:"accent"`
+		MaxMessages *int    `json:"max_messages"`
+		Language    *string `json:"language"`
+		Sort        *string `json:"sort"`
+		Moderation  *string `json:"moderation"`
+
+		BlockedWords *[]string `json:"blocked_words"`
+		Reactions    *[]string `json:"reactions"`
+
+		ReactionsMode *string `json:"reactions_mode"`
+		HideDislikers *bool   `json:"hide_dislikers"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+
+	settings, err := loadSettings(h.db, ownerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to update settings")})
+		return
+	}
+
+	if req.Theme != nil {
+		if !isKnownTheme(*req.Theme) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, errUnknownTheme.Error())})
+			return
+		}
+		settings.Theme = *req.Theme
+	}
+
+	colors := []struct {
+		value *string
+		saved **string
+	}{
+		{req.Bg, &settings.Bg},
+		{req.Text, &settings.Text},
+		{req.Border, &settings.Border},
+		{req.Accent, &settings.Accent},
+	}
+	for _, col := range colors {
+		if col.value == nil {
+			continue
+		}
+		if *col.value == "" {
+			*col.saved = nil
+			continue
+		}
+		normalized, err := normalizeColor(*col.value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		*col.saved = &normalized
+	}
+
+	if req.MaxMessages != nil {
+		switch {
+		case *req.MaxMessages == 0:
+			settings.MaxMessages = nil
+		case *req.MaxMessages < 0 || *req.MaxMessages > maxSVGLimit:
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, errInvalidLimit.Error())})
+			return
+		default:
+			settings.MaxMessages = req.MaxMessages
+		}
+	}
+
+	if req.Language != nil {
+		if *req.Language == "" {
+			settings.Language = nil
+		} else {
+			lang, ok := i18n.Match(*req.Language)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, errUnsupportedLanguage.Error())})
+				return
+			}
+			settings.Language = &lang
+		}
+	}
+
+	if req.Sort != nil {
+		if *req.Sort == "" {
+			settings.Sort = nil
+		} else {
+			if _, ok := messageSorts[*req.Sort]; !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, errUnknownSort.Error())})
+				return
+			}
+			settings.Sort = req.Sort
+		}
+	}
+
+	if req.Moderation != nil {
+		if *req.Moderation == "" {
+			settings.Moderation = nil
+		} else {
+			if !moderationModes[*req.Moderation] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, errUnknownModeration.Error())})
+				return
+			}
+			settings.Moderation = req.Moderation
+		}
+	}
+
+	if req.BlockedWords != nil {
+		words := filter.NormalizeWords(*req.BlockedWords)
+		if len(words) > maxBlockedWords {
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, errTooManyBlockedWords.Error())})
+			return
+		}
+		settings.BlockedWords = words
+		if len(words) == 0 {
+			settings.BlockedWords = nil
+		}
+	}
+
+	// An empty list enables the whole palette again.
+	if req.Reactions != nil {
+		settings.Reactions = nil
+		for _, e := range *req.Reactions {
+			emoji, ok := matchReaction(e)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, errUnknownReaction.Error())})
+				return
+			}
+			if !slices.Contains(settings.Reactions, emoji) {
+				settings.Reactions = append(settings.Reactions, emoji)
+			}
+		}
+	}
+
+	if req.ReactionsMode != nil {
+		if *req.ReactionsMode == "" {
+			settings.ReactionsMode = nil
+		} else {
+			if !reactionsModes[*req.ReactionsMode] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, errUnknownReactionsMode.Error())})
+				return
+			}
+			settings.ReactionsMode = req.ReactionsMode
+		}
+	}
+	if req.HideDislikers != nil {
+		settings.HideDislikers = *req.HideDislikers
+	}
+	if req.Sort != nil && *req.Sort == sortControversial && !countsDislikes(settings) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, errDislikesOff.Error())})
+		return
+	}
+
+	if err := saveSettings(h.db, ownerID, settings); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to update settings")})
+		return
+	}
+	h.cache.Invalidate(ownerID)
+
+	c.JSON(http.StatusOK, settings)
+}
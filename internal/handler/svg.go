@@ -2,197 +2,520 @@ package handler
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"html/template"
+	"math"
 	"net/http"
-	"strings"
+	"slices"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/in-jun/github-profile-guestbook/internal/i18n"
+	"github.com/in-jun/github-profile-guestbook/internal/markup"
 	"github.com/in-jun/github-profile-guestbook/internal/model"
 )
 
+const (
+	defaultSVGLimit = 10
+	maxSVGLimit     = 50
+
+	// Seconds each carousel slide is shown for.
+	defaultCarouselInterval = 5
+	minCarouselInterval     = 2
+	maxCarouselInterval     = 60
+)
+
+var (
+	errInvalidLimit      = errors.New("Invalid limit")
+	errInvalidOffset     = errors.New("Invalid offset")
+	errUnknownLayout     = errors.New("Unknown layout")
+	errUnknownMode       = errors.New("Unknown mode")
+	errInvalidInterval   = errors.New("Invalid interval")
+	errInvalidTimestamps = errors.New("Invalid timestamps")
+)
+
 type SVGHandler struct {
-	db *sql.DB
+	db      *sql.DB
+	cache   *RenderCache
+	avatars *avatarCache
 }
 
-func NewSVGHandler(db *sql.DB) *SVGHandler {
-	return &SVGHandler{db: db}
+type SVGHandlerConfig struct {
+	AvatarsURL     string
+	AvatarCacheTTL int
 }
 
-func (h *SVGHandler) GetSVG(c *gin.Context) {
+func NewSVGHandler(db *sql.DB, cache *RenderCache, cfg *SVGHandlerConfig) *SVGHandler {
+	return &SVGHandler{
+		db:      db,
+		cache:   cache,
+		avatars: newAvatarCache(cfg.AvatarsURL, time.Duration(cfg.AvatarCacheTTL)*time.Second),
+	}
+}
+
+type renderOptions struct {
+	theme    theme
+	lang     string
+	layout   cardLayout
+	limit    int
+	limitSet bool
+	offset   int
+	interval time.Duration
+	sort     string
+	filter   messageFilter
+
+	timestamps bool
+}
+
+func parseRenderOptions(c *gin.Context, settings model.GuestbookSettings) (renderOptions, error) {
+	var opts renderOptions
+	var err error
+
+	if opts.theme, err = resolveTheme(c, settings); err != nil {
+		return opts, err
+	}
+	if opts.lang, err = resolveLanguage(c, settings); err != nil {
+		return opts, err
+	}
+	if opts.sort, err = parseSort(c, settings); err != nil {
+		return opts, err
+	}
+	if opts.filter, err = parseMessageFilter(c); err != nil {
+		return opts, err
+	}
+
+	layout, ok := cardLayouts[c.DefaultQuery("layout", "full")]
+	if !ok {
+		return opts, errUnknownLayout
+	}
+	opts.layout = layout
+
+	switch c.DefaultQuery("mode", "static") {
+	case "static":
+	case "carousel":
+		opts.layout = carouselLayout{}
+	default:
+		return opts, errUnknownMode
+	}
+
+	interval := defaultCarouselInterval
+	if q := c.Query("interval"); q != "" {
+		interval, err = strconv.Atoi(q)
+		if err != nil || interval < minCarouselInterval || interval > maxCarouselInterval {
+			return opts, errInvalidInterval
+		}
+	}
+	opts.interval = time.Duration(interval) * time.Second
+
+	if q := c.Query("timestamps"); q != "" {
+		if opts.timestamps, err = strconv.ParseBool(q); err != nil {
+			return opts, errInvalidTimestamps
+		}
+	}
+
+	opts.limit = defaultSVGLimit
+	if settings.MaxMessages != nil {
+		opts.limit = *settings.MaxMessages
+	}
+	if q := c.Query("limit"); q != "" {
+		opts.limit, err = strconv.Atoi(q)
+		if err != nil || opts.limit < 1 || opts.limit > maxSVGLimit {
+			return opts, errInvalidLimit
+		}
+		opts.limitSet = true
+	}
+	if q := c.Query("offset"); q != "" {
+		opts.offset, err = strconv.Atoi(q)
+		if err != nil || opts.offset < 0 {
+			return opts, errInvalidOffset
+		}
+	}
+	return opts, nil
+}
+
+type guestbookCard struct {
+	username  string
+	claimed   bool
+	theme     theme
+	lang      string
+	layout    cardLayout
+	interval  time.Duration
+	messages  []model.SvgMessageModel
+	avatars   map[int64]*avatarImage
+	total     int
+	starred   int
+	remaining int
+	// timestamps adds each message's age, as of now, to the layouts that
+	// show it.
+	timestamps bool
+	now        time.Time
+	// reactions are the reactions enabled on the guestbook; only these get
+	// chips.
+	reactions []string
+	// avatarsPending is set when some avatars were drawn as initials because
+	// they had not been fetched yet.
+	avatarsPending bool
+}
+
+func (card *guestbookCard) draw(cv canvas) {
+	card.layout.draw(cv, card)
+}
+
+// prepareCard resolves the receiver and the rendering options from the
+// request's query parameters and the owner's saved settings. On failure it
+// writes the error response itself. The returned receiver ID is zero for
+// unclaimed guestbooks.
+func (h *SVGHandler) prepareCard(c *gin.Context) (*guestbookCard, renderOptions, int64, bool) {
 	username := c.Param("username")
 
-	var exists bool
-	h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE github_login = $1)", username).Scan(&exists)
-	if !exists {
-		svgContent := generateLoginPromptSVG(username)
-		c.Writer.Header().Set("Content-Type", "image/svg+xml")
-		c.Writer.Header().Set("Cache-Control", "no-cache")
-		c.String(http.StatusOK, svgContent)
-		return
+	var receiverID int64
+	err := h.db.QueryRow("SELECT id FROM users WHERE github_login = $1", username).Scan(&receiverID)
+	if err == sql.ErrNoRows {
+		opts, err := parseRenderOptions(c, defaultSettings())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+			return nil, opts, 0, false
+		}
+		return &guestbookCard{username: username, theme: opts.theme, lang: opts.lang, layout: opts.layout, interval: opts.interval}, opts, 0, true
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get messages")})
+		return nil, renderOptions{}, 0, false
 	}
 
-	rows, err := h.db.Query(`SELECT
-		m.id,
-		a.github_login,
-		m.content,
-		m.is_owner_liked,
-		COUNT(CASE WHEN r.type = 1 THEN 1 END)   AS likes,
-		COUNT(CASE WHEN r.type = -1 THEN 1 END)  AS dislikes
-	FROM messages m
-	JOIN users a          ON a.id = m.author_id
-	JOIN users recv       ON recv.id = m.receiver_id
-	LEFT JOIN reactions r ON r.message_id = m.id
-	WHERE recv.github_login = $1
-	GROUP BY m.id, a.github_login, m.content, m.is_owner_liked
-	ORDER BY
-		m.is_owner_liked DESC,
-		(COUNT(CASE WHEN r.type = 1 THEN 1 END) - COUNT(CASE WHEN r.type = -1 THEN 1 END)) DESC`, username)
+	settings, err := loadSettings(h.db, receiverID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get messages"})
-		return
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get messages")})
+		return nil, renderOptions{}, 0, false
+	}
+
+	opts, err := parseRenderOptions(c, settings)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+		return nil, opts, 0, false
+	}
+
+	card := &guestbookCard{
+		username: username,
+		claimed:  true,
+		theme:    opts.theme,
+		lang:     opts.lang,
+		layout:   opts.layout,
+		interval: opts.interval,
+		messages: make([]model.SvgMessageModel, 0),
+		avatars:  make(map[int64]*avatarImage),
+
+		timestamps: opts.timestamps,
+		reactions:  allowedReactions(settings),
+	}
+	return card, opts, receiverID, true
+}
+
+// loadMessages fills in the counts and the page of messages the card's
+// layout asks for.
+func (h *SVGHandler) loadMessages(card *guestbookCard, receiverID int64, opts renderOptions) error {
+	q := newMessageQuery(receiverID, nil)
+	q.noDislikes = !slices.Contains(card.reactions, reactionDislike)
+	q.filter(opts.filter)
+	if err := h.db.QueryRow(q.countSQL(), q.args...).Scan(&card.total, &card.starred); err != nil {
+		return err
+	}
+
+	page := opts.layout.page(opts)
+	if page.limit == 0 {
+		return nil
+	}
+	if page.starredOnly {
+		q.filter(messageFilter{starredOnly: true})
+	}
+	q.sortBy(opts.sort, shuffleDay(time.Now()))
+
+	rows, err := h.db.Query(q.selectSQL(page.limit, page.offset,
+		"m.id", "a.github_login", "a.github_id", "m.content", "m.is_owner_liked", "rc.likes", "rc.dislikes",
+		"COALESCE(rp.content, '')", "m.created_at", reactionCountsColumn("NULL"),
+	), q.args...)
+	if err != nil {
+		return err
 	}
 	defer rows.Close()
 
-	messages := make([]model.SvgMessageModel, 0)
 	for rows.Next() {
 		var cm model.SvgMessageModel
-		if err := rows.Scan(&cm.ID, &cm.Author, &cm.Content, &cm.IsOwnerLiked, &cm.Likes, &cm.Dislikes); err != nil {
-			continue
+		var reactions []byte
+		if err := rows.Scan(&cm.ID, &cm.Author, &cm.AuthorGitHubID, &cm.Content, &cm.IsOwnerLiked, &cm.Likes, &cm.Dislikes, &cm.Reply, &cm.CreatedAt, &reactions); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(reactions, &cm.Reactions); err != nil {
+			return err
+		}
+		cm.Markup = markup.Parse(cm.Content)
+		card.messages = append(card.messages, cm)
+		if img := h.avatars.get(cm.AuthorGitHubID); img != nil {
+			card.avatars[cm.AuthorGitHubID] = img
+		} else {
+			card.avatarsPending = true
+		}
+	}
+
+	available := card.total
+	if page.starredOnly {
+		available = card.starred
+	}
+	card.remaining = available - page.offset - len(card.messages)
+	card.now = time.Now()
+
+	return rows.Err()
+}
+
+// serveCard renders the requested guestbook with draw, going through the
+// render cache for claimed guestbooks, and writes it as contentType.
+func (h *SVGHandler) serveCard(c *gin.Context, format, contentType string, draw func(card *guestbookCard) ([]byte, error)) {
+	card, opts, receiverID, ok := h.prepareCard(c)
+	if !ok {
+		return
+	}
+
+	if !card.claimed {
+		body, err := draw(card)
+		if errors.Is(err, errImageTooLarge) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to render image")})
+			return
 		}
-		messages = append(messages, cm)
+		writeRendered(c, contentType, newRenderedImage(body))
+		return
 	}
 
-	svgContent := generateMessageBox(username, messages)
+	img, err := h.cache.get(receiverID, renderKey(c, format), func() ([]byte, time.Duration, error) {
+		if err := h.loadMessages(card, receiverID, opts); err != nil {
+			return nil, 0, err
+		}
+		body, err := draw(card)
+		if err != nil {
+			return nil, 0, err
+		}
+		if card.avatarsPending {
+			return body, renderPendingTTL, nil
+		}
+		return body, h.cache.ttl, nil
+	})
+	if errors.Is(err, errImageTooLarge) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tr(c, err.Error())})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get messages")})
+		return
+	}
+
+	writeRendered(c, contentType, img)
+}
 
-	c.Writer.Header().Set("Content-Type", "image/svg+xml")
-	c.Writer.Header().Set("Cache-Control", "no-cache")
-	c.String(http.StatusOK, svgContent)
+func (h *SVGHandler) GetSVG(c *gin.Context) {
+	h.serveCard(c, "svg", "image/svg+xml", func(card *guestbookCard) ([]byte, error) {
+		cv := newSVGCanvas(card.theme, card.lang)
+		card.draw(cv)
+		return []byte(cv.String()), nil
+	})
 }
 
-func generateMessageBox(userName string, messages []model.SvgMessageModel) string {
+const (
+	messageBoxBaseHeight     = 102
+	messageBoxPadding        = 12
+	messageContentFontSize   = 14
+	messageContentLineHeight = 21
+	maxMessageContentLines   = 5
+	emptyMessagesHeight      = 80
+	messageReplyHeight       = 30
+	messageReplyFontSize     = 13
+)
+
+// wrapMessage wraps a message's content for a box of the given width and
+// returns the lines along with the box height they need.
+func wrapMessage(message model.SvgMessageModel, boxWidth int) ([][]textRun, int) {
+	lines := wrapMarkup(message.Markup, messageContentFontSize, float64(boxWidth-32), maxMessageContentLines)
+	return lines, messageBoxBaseHeight + (len(lines)-1)*messageContentLineHeight
+}
+
+// drawMessageBox draws a bordered message with its author, content and
+// reaction chips.
+func drawMessageBox(cv canvas, card *guestbookCard, message model.SvgMessageModel, x, y, width, height int, lines [][]textRun) {
 	const (
-		width                  = 800
-		padding                = 24
-		headerFontSize         = 24
-		headerBaseline         = 18
-		headerBottomGap        = 16
-		sectionTitleFontSize   = 14
-		sectionTitleBaseline   = 11
-		sectionTitleTopGap     = 24
-		sectionTitleBottomGap  = 24
-		titleDescent           = 3
-		messageBoxHeight       = 102
-		messageBoxGap          = 16
-		messageBoxPadding      = 12
-		buttonHeight           = 24
-		buttonGap              = 8
-		likeWidth              = 50
-		dislikeWidth           = 50
-		starWidth              = 32
-		emptyBoxHeight         = 80
-		bottomPadding          = 24
+		buttonHeight = 24
+		buttonGap    = 8
+		likeWidth    = 50
+		dislikeWidth = 50
+		starWidth    = 32
 	)
 
-	headerTextY := padding + headerBaseline
-	headerLineY := headerTextY + headerBottomGap
-	sectionTitleY := headerLineY + sectionTitleTopGap + sectionTitleBaseline
-	messagesStartY := sectionTitleY + titleDescent + sectionTitleBottomGap
+	cv.rect(x, y, width, height, colorNone, colorBorder)
 
-	var totalHeight int
-	if len(messages) == 0 {
-		totalHeight = messagesStartY + emptyBoxHeight + bottomPadding
-	} else {
-		messagesHeight := len(messages)*messageBoxHeight + (len(messages)-1)*messageBoxGap
-		totalHeight = messagesStartY + messagesHeight + bottomPadding
+	textX := x + 16
+	authorY := y + messageBoxPadding + 11
+	drawAuthor(cv, textX, authorY, message, card.avatars[message.AuthorGitHubID])
+	if card.timestamps {
+		cv.text(x+width-16, authorY, relativeTime(card.lang, message.CreatedAt, card.now),
+			textStyle{size: 12, color: colorGray, anchor: anchorEnd})
+	}
+
+	contentY := y + messageBoxPadding + 21 + 4
+	contentHeight := len(lines) * messageContentLineHeight
+	cv.textLines(textX, contentY+15, messageContentLineHeight, lines, textStyle{size: messageContentFontSize, color: colorText})
+
+	chipStyle := textStyle{size: 12, weight: 500, color: colorText, anchor: anchorMiddle, middle: true}
+	buttonY := contentY + contentHeight + 8 + buttonHeight/2
+	currentX := textX
+
+	if slices.Contains(card.reactions, reactionLike) {
+		drawChip(cv, currentX, buttonY, likeWidth, buttonHeight, fmt.Sprintf("+ %d", message.Likes), chipStyle)
+		currentX += likeWidth + buttonGap
+	}
+	if slices.Contains(card.reactions, reactionDislike) {
+		drawChip(cv, currentX, buttonY, dislikeWidth, buttonHeight, fmt.Sprintf("- %d", message.Dislikes), chipStyle)
+		currentX += dislikeWidth + buttonGap
 	}
 
-	var builder strings.Builder
+	for _, label := range topReactions(message.Reactions, card.reactions) {
+		w := int(math.Ceil(measureText(label, float64(chipStyle.size)))) + 20
+		drawChip(cv, currentX, buttonY, w, buttonHeight, label, chipStyle)
+		currentX += w + buttonGap
+	}
+
+	if message.IsOwnerLiked {
+		drawChip(cv, currentX, buttonY, starWidth, buttonHeight, "★", textStyle{size: 12, color: colorAccent, anchor: anchorMiddle, middle: true})
+	}
+}
 
-	builder.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">`, width, totalHeight))
-	builder.WriteString(`<style>
-		svg { --bg-color: white; --text-color: black; --border-color: #e0e0e0; --gray-color: #666666; }
-		@media (prefers-color-scheme: dark) {
-			svg { --bg-color: black; --text-color: white; --border-color: #333333; --gray-color: #999999; }
+// topReactions labels the most used of the enabled reactions other than
+// likes and dislikes, which have chips of their own.
+func topReactions(reactions []model.ReactionCount, enabled []string) []string {
+	const maxReactionChips = 3
+
+	var labels []string
+	for _, r := range reactions {
+		if r.Emoji == reactionLike || r.Emoji == reactionDislike || !slices.Contains(enabled, r.Emoji) {
+			continue
 		}
-		text { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; }
-	</style>`)
+		labels = append(labels, fmt.Sprintf("%s %d", r.Emoji, r.Count))
+		if len(labels) == maxReactionChips {
+			break
+		}
+	}
+	return labels
+}
+
+// replyHeight is the space a message's reply takes below its box.
+func replyHeight(message model.SvgMessageModel) int {
+	if message.Reply == "" {
+		return 0
+	}
+	return messageReplyHeight
+}
 
-	builder.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="var(--bg-color)"/>`, width, totalHeight))
+// drawReply draws the owner's reply as an indented line hanging off the
+// bottom of a message box, at y.
+func drawReply(cv canvas, card *guestbookCard, message model.SvgMessageModel, x, y, width int) {
+	const (
+		indent    = 16
+		elbow     = 12
+		textGap   = 8
+		authorGap = 6
+	)
+
+	if message.Reply == "" {
+		return
+	}
 
-	builder.WriteString(fmt.Sprintf(`<text x="%d" y="%d" font-size="%d" font-weight="700" fill="var(--text-color)">%s</text>`,
-		padding, headerTextY, headerFontSize, template.HTMLEscapeString(userName)))
+	lineX := x + indent
+	centerY := y + messageReplyHeight/2 + 2
+	cv.line(lineX, y, lineX, centerY, colorBorder)
+	cv.line(lineX, centerY, lineX+elbow, centerY, colorBorder)
 
-	builder.WriteString(fmt.Sprintf(`<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="var(--border-color)" stroke-width="1"/>`,
-		padding, headerLineY, width-padding, headerLineY))
+	textX := lineX + elbow + textGap
+	cv.text(textX, centerY, card.username, textStyle{size: messageReplyFontSize, weight: 700, color: colorText, middle: true})
 
-	builder.WriteString(fmt.Sprintf(`<text x="%d" y="%d" font-size="%d" font-weight="700" fill="var(--gray-color)" letter-spacing="0.5">GUESTBOOK</text>`,
-		padding, sectionTitleY, sectionTitleFontSize))
+	replyX := textX + int(math.Ceil(measureText(card.username, messageReplyFontSize))) + authorGap
+	reply := wrapText(message.Reply, messageReplyFontSize, float64(x+width-16-replyX), 1)[0]
+	cv.text(replyX, centerY, reply, textStyle{size: messageReplyFontSize, color: colorGray, middle: true})
+}
 
-	if len(messages) == 0 {
-		emptyY := messagesStartY
-		builder.WriteString(fmt.Sprintf(`<rect x="%d" y="%d" width="%d" height="%d" fill="none" stroke="var(--border-color)" stroke-width="1"/>`,
-			padding, emptyY, width-padding*2, emptyBoxHeight))
+func drawEmptyMessages(cv canvas, card *guestbookCard, x, y, width int) {
+	cv.rect(x, y, width, emptyMessagesHeight, colorNone, colorBorder)
 
-		iconBaseline := emptyY + 15 + 18
-		builder.WriteString(fmt.Sprintf(`<text x="%d" y="%d" font-size="24" font-weight="700" fill="var(--gray-color)" text-anchor="middle">—</text>`,
-			width/2, iconBaseline))
+	iconBaseline := y + 15 + 18
+	cv.text(x+width/2, iconBaseline, "—", textStyle{size: 24, weight: 700, color: colorGray, anchor: anchorMiddle})
 
-		textBaseline := iconBaseline + 6 + 12 + 11
-		builder.WriteString(fmt.Sprintf(`<text x="%d" y="%d" font-size="14" fill="var(--gray-color)" text-anchor="middle">No messages yet</text>`,
-			width/2, textBaseline))
-	} else {
-		for i, message := range messages {
-			messageY := messagesStartY + i*(messageBoxHeight+messageBoxGap)
+	textBaseline := iconBaseline + 6 + 12 + 11
+	cv.text(x+width/2, textBaseline, i18n.T(card.lang, "No messages yet"), textStyle{size: 14, color: colorGray, anchor: anchorMiddle})
+}
 
-			builder.WriteString(fmt.Sprintf(`<rect x="%d" y="%d" width="%d" height="%d" fill="none" stroke="var(--border-color)" stroke-width="1"/>`,
-				padding, messageY, width-padding*2, messageBoxHeight))
+// generateMessageBox draws one page of the card's messages; messages after
+// this page are summarized in a footer row.
+func generateMessageBox(cv canvas, card *guestbookCard) {
+	const (
+		width         = 800
+		padding       = cardPadding
+		boxWidth      = width - padding*2
+		messageBoxGap = 16
+		footerTopGap  = 16
+		footerHeight  = 20
+		bottomPadding = 24
+	)
 
-			textX := padding + 16
-			textWidth := width - padding*2 - 32
-			authorY := messageY + messageBoxPadding + 11
-			builder.WriteString(fmt.Sprintf(`<text x="%d" y="%d" font-size="14" font-weight="700" fill="var(--text-color)">%s</text>`,
-				textX, authorY, template.HTMLEscapeString(message.Author)))
+	messagesStartY := cardHeaderHeight
+
+	contentLines := make([][][]textRun, len(card.messages))
+	boxHeights := make([]int, len(card.messages))
+	messagesHeight := 0
+	for i, message := range card.messages {
+		contentLines[i], boxHeights[i] = wrapMessage(message, boxWidth)
+		messagesHeight += boxHeights[i] + replyHeight(message)
+		if i > 0 {
+			messagesHeight += messageBoxGap
+		}
+	}
 
-			contentY := messageY + messageBoxPadding + 21 + 4
-			contentHeight := 21
-			builder.WriteString(fmt.Sprintf(`<foreignObject x="%d" y="%d" width="%d" height="%d">`,
-				textX, contentY, textWidth, contentHeight))
-			builder.WriteString(`<div xmlns="http://www.w3.org/1999/xhtml" style="margin: 0; padding: 0; font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; height: 100%;">`)
-			builder.WriteString(fmt.Sprintf(`<div style="margin: 0; padding: 0; box-sizing: border-box; font-size: 14px; color: var(--text-color); line-height: 1.5; display: -webkit-box; -webkit-line-clamp: 1; -webkit-box-orient: vertical; overflow: hidden; word-break: break-word;">%s</div>`,
-				template.HTMLEscapeString(message.Content)))
-			builder.WriteString(`</div>`)
-			builder.WriteString(`</foreignObject>`)
+	var totalHeight int
+	if len(card.messages) == 0 {
+		totalHeight = messagesStartY + emptyMessagesHeight + bottomPadding
+	} else {
+		totalHeight = messagesStartY + messagesHeight + bottomPadding
+	}
+	footerY := totalHeight - bottomPadding + footerTopGap
+	if card.remaining > 0 {
+		totalHeight += footerTopGap + footerHeight
+	}
 
-			buttonY := contentY + 21 + 8 + buttonHeight/2
-			currentX := textX
+	cv.begin(width, totalHeight)
+	cv.rect(0, 0, width, totalHeight, colorBg, colorNone)
 
-			builder.WriteString(fmt.Sprintf(`<rect x="%d" y="%d" width="%d" height="%d" fill="none" stroke="var(--border-color)" stroke-width="1"/>`,
-				currentX, buttonY-buttonHeight/2, likeWidth, buttonHeight))
-			builder.WriteString(fmt.Sprintf(`<text x="%d" y="%d" font-size="12" font-weight="500" fill="var(--text-color)" text-anchor="middle" dominant-baseline="middle">+ %d</text>`,
-				currentX+likeWidth/2, buttonY, message.Likes))
-			currentX += likeWidth + buttonGap
+	drawCardHeader(cv, width, card.username, i18n.T(card.lang, "GUESTBOOK"))
 
-			builder.WriteString(fmt.Sprintf(`<rect x="%d" y="%d" width="%d" height="%d" fill="none" stroke="var(--border-color)" stroke-width="1"/>`,
-				currentX, buttonY-buttonHeight/2, dislikeWidth, buttonHeight))
-			builder.WriteString(fmt.Sprintf(`<text x="%d" y="%d" font-size="12" font-weight="500" fill="var(--text-color)" text-anchor="middle" dominant-baseline="middle">- %d</text>`,
-				currentX+dislikeWidth/2, buttonY, message.Dislikes))
-			currentX += dislikeWidth + buttonGap
+	if len(card.messages) == 0 {
+		drawEmptyMessages(cv, card, padding, messagesStartY, boxWidth)
+	}
 
-			if message.IsOwnerLiked {
-				builder.WriteString(fmt.Sprintf(`<rect x="%d" y="%d" width="%d" height="%d" fill="none" stroke="var(--border-color)" stroke-width="1"/>`,
-					currentX, buttonY-buttonHeight/2, starWidth, buttonHeight))
-				builder.WriteString(fmt.Sprintf(`<text x="%d" y="%d" font-size="12" fill="var(--text-color)" text-anchor="middle" dominant-baseline="middle">★</text>`,
-					currentX+starWidth/2, buttonY))
-			}
+	messageY := messagesStartY
+	for i, message := range card.messages {
+		if i > 0 {
+			messageY += boxHeights[i-1] + replyHeight(card.messages[i-1]) + messageBoxGap
 		}
+		drawMessageBox(cv, card, message, padding, messageY, boxWidth, boxHeights[i], contentLines[i])
+		drawReply(cv, card, message, padding, messageY+boxHeights[i], boxWidth)
 	}
 
-	builder.WriteString("</svg>")
-	return builder.String()
+	if card.remaining > 0 {
+		cv.text(width/2, footerY+footerHeight/2, i18n.Tf(card.lang, "+ %d more — view all", card.remaining),
+			textStyle{size: 13, color: colorGray, anchor: anchorMiddle, middle: true})
+	}
 }
 
-func generateLoginPromptSVG(userName string) string {
+func generateLoginPrompt(cv canvas, userName, lang string) {
 	const (
 		width           = 800
 		height          = 160
@@ -205,35 +528,19 @@ func generateLoginPromptSVG(userName string) string {
 		textGap          = 24
 	)
 
-	var builder strings.Builder
-
-	builder.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">`, width, height))
-	builder.WriteString(`<style>
-		svg { --bg-color: white; --text-color: black; --border-color: #e0e0e0; --gray-color: #666666; }
-		@media (prefers-color-scheme: dark) {
-			svg { --bg-color: black; --text-color: white; --border-color: #333333; --gray-color: #999999; }
-		}
-		text { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; }
-	</style>`)
-
-	builder.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="var(--bg-color)"/>`, width, height))
+	cv.begin(width, height)
+	cv.rect(0, 0, width, height, colorBg, colorNone)
 
 	usernameY := padding + usernameBaseline
-	builder.WriteString(fmt.Sprintf(`<text x="%d" y="%d" font-size="%d" font-weight="700" fill="var(--text-color)">%s</text>`,
-		padding, usernameY, usernameFontSize, template.HTMLEscapeString(userName)))
+	cv.text(padding, usernameY, userName, textStyle{size: usernameFontSize, weight: 700, color: colorText})
 
 	lineY := usernameY + lineGap
-	builder.WriteString(fmt.Sprintf(`<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="var(--border-color)" stroke-width="1"/>`,
-		padding, lineY, width-padding, lineY))
+	cv.line(padding, lineY, width-padding, lineY, colorBorder)
 
 	messageY := lineY + textGap + messageBaseline
-	builder.WriteString(fmt.Sprintf(`<text x="%d" y="%d" font-size="%d" fill="var(--gray-color)">This profile hasn't been claimed yet.</text>`,
-		padding, messageY, messageFontSize))
+	cv.text(padding, messageY, i18n.T(lang, "This profile hasn't been claimed yet."), textStyle{size: messageFontSize, color: colorGray})
 
 	subMessageY := messageY + textGap
-	builder.WriteString(fmt.Sprintf(`<text x="%d" y="%d" font-size="%d" fill="var(--gray-color)">Visit github-profile-guestbook.injun.dev/%s to claim this profile.</text>`,
-		padding, subMessageY, messageFontSize, template.HTMLEscapeString(userName)))
-
-	builder.WriteString("</svg>")
-	return builder.String()
+	cv.text(padding, subMessageY, i18n.Tf(lang, "Visit github-profile-guestbook.injun.dev/%s to claim this profile.", userName),
+		textStyle{size: messageFontSize, color: colorGray})
 }
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/in-jun/github-profile-guestbook/internal/markup"
+)
+
+const ellipsis = "…"
+
+// runeWidth approximates the advance of r in a proportional sans-serif face,
+// in em units. The numbers are tuned against the system font stacks used by
+// the SVG and err slightly wide so wrapped lines never overflow.
+func runeWidth(r rune) float64 {
+	switch {
+	case r == '\t':
+		return 1.1
+	case isZeroWidth(r):
+		return 0
+	case isEmoji(r):
+		return 1.25
+	case isWide(r):
+		return 1.0
+	case r == ' ':
+		return 0.28
+	case strings.ContainsRune("iIl.,:;'|!`", r):
+		return 0.28
+	case strings.ContainsRune("fjrt()[]{}\"-/\\", r):
+		return 0.38
+	case strings.ContainsRune("mwMW@%", r):
+		return 0.9
+	case r >= 'A' && r <= 'Z':
+		return 0.68
+	case r >= '0' && r <= '9':
+		return 0.56
+	case r < 0x80:
+		return 0.54
+	case unicode.IsUpper(r):
+		return 0.68
+	default:
+		return 0.58
+	}
+}
+
+func isZeroWidth(r rune) bool {
+	return r == 0x200B || r == 0x200C || r == 0x200D || r == 0xFEFF ||
+		(r >= 0xFE00 && r <= 0xFE0F) ||
+		(r >= 0x1F3FB && r <= 0x1F3FF) ||
+		unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf)
+}
+
+func isEmoji(r rune) bool {
+	return (r >= 0x1F300 && r <= 0x1FAFF) ||
+		(r >= 0x2600 && r <= 0x27BF) ||
+		(r >= 0x1F000 && r <= 0x1F2FF) ||
+		(r >= 0x2B00 && r <= 0x2BFF)
+}
+
+func isWide(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hangul, unicode.Hiragana, unicode.Katakana) ||
+		(r >= 0x3000 && r <= 0x303F) ||
+		(r >= 0xFF01 && r <= 0xFF60) ||
+		(r >= 0xFFE0 && r <= 0xFFE6)
+}
+
+// breaksAnywhere reports whether a line may be broken before or after r
+// without a space, as is customary for CJK text.
+func breaksAnywhere(r rune) bool {
+	return isWide(r) || isEmoji(r)
+}
+
+func measureText(s string, fontSize float64) float64 {
+	var w float64
+	for _, r := range s {
+		w += runeWidth(r)
+	}
+	return w * fontSize
+}
+
+// wrapText breaks s into lines no wider than maxWidth pixels. When the text
+// needs more than maxLines lines, the last line is cut and ends in an
+// ellipsis.
+func wrapText(s string, fontSize, maxWidth float64, maxLines int) []string {
+	text := []rune(s)
+	widths := make([]float64, len(text))
+	for i, r := range text {
+		widths[i] = runeWidth(r) * fontSize
+	}
+
+	ranges := wrapRunes(text, widths, measureText(ellipsis, fontSize), maxWidth, maxLines)
+	lines := make([]string, len(ranges))
+	for i, lr := range ranges {
+		lines[i] = string(text[lr.start:lr.end])
+		if lr.truncated {
+			lines[i] += ellipsis
+		}
+	}
+	return lines
+}
+
+// Formatted runes are measured wider than plain ones: bold by a fixed
+// factor, and code in a monospace face that gives every narrow character the
+// same advance.
+const (
+	boldWidthFactor = 1.1
+	monoRuneWidth   = 0.62
+)
+
+// wrapMarkup wraps formatted text like wrapText and splits each line into
+// runs that keep the formatting of the text they came from.
+func wrapMarkup(nodes []markup.Node, fontSize, maxWidth float64, maxLines int) [][]textRun {
+	var text []rune
+	var widths []float64
+	var owner []int // index into spans for each rune of text
+	spans := markup.Spans(nodes)
+	for i, span := range spans {
+		for _, r := range span.Text {
+			w := runeWidth(r)
+			if span.Code && w > 0 && !isWide(r) && !isEmoji(r) {
+				w = monoRuneWidth
+			}
+			if span.Bold {
+				w *= boldWidthFactor
+			}
+			text = append(text, r)
+			widths = append(widths, w*fontSize)
+			owner = append(owner, i)
+		}
+	}
+
+	ranges := wrapRunes(text, widths, measureText(ellipsis, fontSize), maxWidth, maxLines)
+	lines := make([][]textRun, len(ranges))
+	for i, lr := range ranges {
+		for j := lr.start; j < lr.end; {
+			k := j + 1
+			for k < lr.end && owner[k] == owner[j] {
+				k++
+			}
+			span := spans[owner[j]]
+			lines[i] = append(lines[i], textRun{text: string(text[j:k]), bold: span.Bold, italic: span.Italic, mono: span.Code, href: span.Href})
+			j = k
+		}
+		if lr.truncated {
+			lines[i] = append(lines[i], textRun{text: ellipsis})
+		}
+	}
+	return lines
+}
+
+// lineRange is a wrapped line: the runes [start, end) of the text, followed
+// by an ellipsis if the text was cut short there.
+type lineRange struct {
+	start, end int
+	truncated  bool
+}
+
+// wrapRunes breaks text, whose runes are widths pixels wide, into lines no
+// wider than maxWidth. When the text needs more than maxLines lines, the
+// last line is shortened to fit an ellipsis ellipsisWidth pixels wide.
+func wrapRunes(text []rune, widths []float64, ellipsisWidth, maxWidth float64, maxLines int) []lineRange {
+	var lines []lineRange
+	start := 0
+	for i := 0; i <= len(text); i++ {
+		if i == len(text) || text[i] == '\n' {
+			lines = append(lines, wrapParagraph(text[:i], widths, start, maxWidth)...)
+			start = i + 1
+		}
+	}
+
+	if len(lines) <= maxLines {
+		return lines
+	}
+
+	lines = lines[:maxLines]
+	last := &lines[maxLines-1]
+	last.truncated = true
+	limit := maxWidth - ellipsisWidth
+	for last.end > last.start && (unicode.IsSpace(text[last.end-1]) || sumWidths(widths, last.start, last.end) > limit) {
+		last.end--
+	}
+	return lines
+}
+
+func sumWidths(widths []float64, start, end int) float64 {
+	var w float64
+	for _, rw := range widths[start:end] {
+		w += rw
+	}
+	return w
+}
+
+// wrapParagraph wraps the runes of text from start on, which contain no
+// line breaks.
+func wrapParagraph(text []rune, widths []float64, start int, maxWidth float64) []lineRange {
+	var lines []lineRange
+	var lineWidth float64
+	lastBreak := -1 // offset from start of the last break opportunity
+
+	flush := func(i, end int) {
+		trimmed := start + end
+		for trimmed > start && unicode.IsSpace(text[trimmed-1]) {
+			trimmed--
+		}
+		lines = append(lines, lineRange{start: start, end: trimmed})
+		start += end
+		for start < i && unicode.IsSpace(text[start]) {
+			start++
+		}
+		lineWidth = sumWidths(widths, start, i)
+		lastBreak = -1
+	}
+
+next:
+	for i := start; i < len(text); i++ {
+		r := text[i]
+		if i == start && unicode.IsSpace(r) {
+			start++
+			continue
+		}
+
+		w := widths[i]
+		for lineWidth+w > maxWidth && i > start && !isZeroWidth(r) {
+			switch {
+			case unicode.IsSpace(r):
+				flush(i, i-start)
+				start = i + 1
+				continue next
+			case breaksAnywhere(r) || lastBreak <= 0:
+				flush(i, i-start)
+			default:
+				flush(i, lastBreak)
+			}
+		}
+
+		if unicode.IsSpace(r) || breaksAnywhere(r) {
+			lastBreak = i - start + 1
+		} else if i > start && breaksAnywhere(text[i-1]) {
+			lastBreak = i - start
+		}
+		lineWidth += w
+	}
+
+	if start < len(text) || len(lines) == 0 {
+		end := len(text)
+		for end > start && unicode.IsSpace(text[end-1]) {
+			end--
+		}
+		lines = append(lines, lineRange{start: start, end: end})
+	}
+	return lines
+}
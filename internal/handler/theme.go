@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/in-jun/github-profile-guestbook/internal/model"
+)
+
+const defaultThemeName = "auto"
+
+var (
+	errUnknownTheme = errors.New("Unknown theme")
+	errInvalidColor = errors.New("Invalid color")
+)
+
+var hexColorPattern = regexp.MustCompile(`^#?([0-9a-fA-F]{3}|[0-9a-fA-F]{4}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+
+type palette struct {
+	Bg     string
+	Text   string
+	Border string
+	Gray   string
+	Accent string
+}
+
+func (p palette) cssVars() string {
+	return fmt.Sprintf("--bg-color: %s; --text-color: %s; --border-color: %s; --gray-color: %s; --accent-color: %s;",
+		p.Bg, p.Text, p.Border, p.Gray, p.Accent)
+}
+
+// A theme with a dark palette follows prefers-color-scheme; otherwise the
+// light palette is used unconditionally.
+type theme struct {
+	light palette
+	dark  *palette
+}
+
+var (
+	lightPalette = palette{Bg: "white", Text: "black", Border: "#e0e0e0", Gray: "#666666", Accent: "black"}
+	darkPalette  = palette{Bg: "black", Text: "white", Border: "#333333", Gray: "#999999", Accent: "white"}
+)
+
+var themePresets = map[string]theme{
+	"auto":          {light: lightPalette, dark: &darkPalette},
+	"light":         {light: lightPalette},
+	"dark":          {light: darkPalette},
+	"github-light":  {light: palette{Bg: "#ffffff", Text: "#1f2328", Border: "#d0d7de", Gray: "#656d76", Accent: "#0969da"}},
+	"github-dark":   {light: palette{Bg: "#0d1117", Text: "#e6edf3", Border: "#30363d", Gray: "#7d8590", Accent: "#2f81f7"}},
+	"dracula":       {light: palette{Bg: "#282a36", Text: "#f8f8f2", Border: "#44475a", Gray: "#6272a4", Accent: "#bd93f9"}},
+	"high-contrast": {light: palette{Bg: "#000000", Text: "#ffffff", Border: "#ffffff", Gray: "#f0f0f0", Accent: "#ffd700"}},
+}
+
+func (t theme) style(fontFamily string) string {
+	var builder strings.Builder
+	builder.WriteString("<style>\n")
+	builder.WriteString(fmt.Sprintf("\t\tsvg { %s }\n", t.light.cssVars()))
+	if t.dark != nil {
+		builder.WriteString("\t\t@media (prefers-color-scheme: dark) {\n")
+		builder.WriteString(fmt.Sprintf("\t\t\tsvg { %s }\n", t.dark.cssVars()))
+		builder.WriteString("\t\t}\n")
+	}
+	builder.WriteString(fmt.Sprintf("\t\ttext { font-family: %s; }\n", fontFamily))
+	builder.WriteString("\t</style>")
+	return builder.String()
+}
+
+type colorOverrides struct {
+	Bg     *string
+	Text   *string
+	Border *string
+	Accent *string
+}
+
+func (t theme) withOverrides(o colorOverrides) theme {
+	apply := func(p palette) palette {
+		if o.Bg != nil {
+			p.Bg = *o.Bg
+		}
+		if o.Text != nil {
+			p.Text = *o.Text
+		}
+		if o.Border != nil {
+			p.Border = *o.Border
+		}
+		if o.Accent != nil {
+			p.Accent = *o.Accent
+		}
+		return p
+	}
+
+	result := theme{light: apply(t.light)}
+	if t.dark != nil {
+		dark := apply(*t.dark)
+		result.dark = &dark
+	}
+	return result
+}
+
+func isKnownTheme(name string) bool {
+	_, ok := themePresets[name]
+	return ok
+}
+
+func normalizeColor(s string) (string, error) {
+	if !hexColorPattern.MatchString(s) {
+		return "", errInvalidColor
+	}
+	return "#" + strings.ToLower(strings.TrimPrefix(s, "#")), nil
+}
+
+// resolveTheme applies the theme and color query parameters on top of the
+// owner's saved defaults.
+func resolveTheme(c *gin.Context, settings model.GuestbookSettings) (theme, error) {
+	name := settings.Theme
+	if q := c.Query("theme"); q != "" {
+		name = q
+	}
+	preset, ok := themePresets[name]
+	if !ok {
+		return theme{}, errUnknownTheme
+	}
+
+	pick := func(param string, saved *string) (*string, error) {
+		v := c.Query(param)
+		if v == "" {
+			return saved, nil
+		}
+		color, err := normalizeColor(v)
+		if err != nil {
+			return nil, err
+		}
+		return &color, nil
+	}
+
+	var overrides colorOverrides
+	var err error
+	if overrides.Bg, err = pick("bg", settings.Bg); err != nil {
+		return theme{}, err
+	}
+	if overrides.Text, err = pick("text", settings.Text); err != nil {
+		return theme{}, err
+	}
+	if overrides.Border, err = pick("border", settings.Border); err != nil {
+		return theme{}, err
+	}
+	if overrides.Accent, err = pick("accent", settings.Accent); err != nil {
+		return theme{}, err
+	}
+
+	return preset.withOverrides(overrides), nil
+}
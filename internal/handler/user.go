@@ -25,7 +25,7 @@ func (h *UserHandler) GetMe(c *gin.Context) {
 	var login string
 	err := h.db.QueryRow("SELECT github_login FROM users WHERE id = $1", userID.(int64)).Scan(&login)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get user")})
 		return
 	}
 
@@ -35,7 +35,7 @@ func (h *UserHandler) GetMe(c *gin.Context) {
 func (h *UserHandler) GetUsers(c *gin.Context) {
 	rows, err := h.db.Query("SELECT id, github_id, github_login FROM users")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get users"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": tr(c, "Failed to get users")})
 		return
 	}
 	defer rows.Close()
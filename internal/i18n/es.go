@@ -0,0 +1,137 @@
+package i18n
+
+var es = map[string]string{
+	// Guestbook images
+	"GUESTBOOK":                             "LIBRO DE VISITAS",
+	"STARRED":                               "DESTACADOS",
+	"No messages yet":                       "Aún no hay mensajes",
+	"No starred messages yet":               "Aún no hay mensajes destacados",
+	"+ %d more — view all":                  "+ %d más — ver todos",
+	"just now":                              "justo ahora",
+	"1 minute ago":                          "hace 1 minuto",
+	"%d minutes ago":                        "hace %d minutos",
+	"1 hour ago":                            "hace 1 hora",
+	"%d hours ago":                          "hace %d horas",
+	"1 day ago":                             "hace 1 día",
+	"%d days ago":                           "hace %d días",
+	"1 month ago":                           "hace 1 mes",
+	"%d months ago":                         "hace %d meses",
+	"1 year ago":                            "hace 1 año",
+	"%d years ago":                          "hace %d años",
+	"guestbook":                             "libro de visitas",
+	"unclaimed":                             "sin reclamar",
+	"1 message":                             "1 mensaje",
+	"%d messages":                           "%d mensajes",
+	"This profile hasn't been claimed yet.": "Este perfil aún no ha sido reclamado.",
+	"Visit github-profile-guestbook.injun.dev/%s to claim this profile.": "Visita github-profile-guestbook.injun.dev/%s para reclamar este perfil.",
+
+	// API errors
+	"Unauthorized":                                   "No autorizado",
+	"rate limit exceeded":                            "Demasiadas solicitudes, inténtalo de nuevo más tarde",
+	"GitHub user not found":                          "Usuario de GitHub no encontrado",
+	"Failed to get user":                             "No se pudo obtener el usuario",
+	"Failed to get users":                            "No se pudieron obtener los usuarios",
+	"Message not found":                              "Mensaje no encontrado",
+	"Invalid Message ID":                             "ID de mensaje no válido",
+	"Content not provided":                           "No se proporcionó contenido",
+	"Invalid content":                                "Contenido no válido",
+	"Links are not allowed":                          "No se permiten enlaces",
+	"Only GitHub links are allowed":                  "Solo se permiten enlaces de GitHub",
+	"Message contains blocked words":                 "El mensaje contiene palabras bloqueadas",
+	"Message was rejected by the content filter":     "El filtro de contenido rechazó el mensaje",
+	"user already has a message":                     "Ya dejaste un mensaje",
+	"Failed to create message":                       "No se pudo crear el mensaje",
+	"Failed to get messages":                         "No se pudieron obtener los mensajes",
+	"Failed to delete message":                       "No se pudo eliminar el mensaje",
+	"Failed to edit message":                         "No se pudo editar el mensaje",
+	"Edit window has expired":                        "El plazo para editar ha terminado",
+	"Failed to get revisions":                        "No se pudo obtener el historial de ediciones",
+	"You can't view this message's revisions":        "No puedes ver el historial de este mensaje",
+	"Only the guestbook owner can reply to messages": "Solo el dueño del libro de visitas puede responder a los mensajes",
+	"Message already has a reply":                    "El mensaje ya tiene una respuesta",
+	"Reply not found":                                "Respuesta no encontrada",
+	"Failed to create reply":                         "No se pudo crear la respuesta",
+	"Failed to edit reply":                           "No se pudo editar la respuesta",
+	"Failed to delete reply":                         "No se pudo eliminar la respuesta",
+	"Failed to get pending messages":                 "No se pudieron obtener los mensajes pendientes",
+	"Pending message not found":                      "Mensaje pendiente no encontrado",
+	"Failed to approve message":                      "No se pudo aprobar el mensaje",
+	"Failed to pin messages":                         "No se pudieron fijar los mensajes",
+	"Too many pinned messages":                       "Demasiados mensajes fijados",
+	"Duplicate message ID":                           "ID de mensaje duplicado",
+	"Reason not provided":                            "No se proporcionó un motivo",
+	"Reason is too long":                             "El motivo es demasiado largo",
+	"Removal reason is too long":                     "El motivo de la eliminación es demasiado largo",
+	"Message IDs not provided":                       "No se indicaron los ID de los mensajes",
+	"Too many messages":                              "Demasiados mensajes",
+	"Failed to delete messages":                      "No se pudieron eliminar los mensajes",
+	"Failed to get removed messages":                 "No se pudieron obtener los mensajes eliminados",
+	"Message was removed by the guestbook owner":     "El propietario del libro de visitas eliminó el mensaje",
+	"Failed to report message":                       "No se pudo denunciar el mensaje",
+	"You can't report your own message":              "No puedes denunciar tu propio mensaje",
+	"You already reported this message":              "Ya denunciaste este mensaje",
+	"Admin access required":                          "Se requiere acceso de administrador",
+	"Failed to get reports":                          "No se pudieron obtener las denuncias",
+	"Invalid report ID":                              "ID de denuncia no válido",
+	"Unknown action":                                 "Acción desconocida",
+	"Failed to resolve report":                       "No se pudo resolver la denuncia",
+	"Report not found":                               "Denuncia no encontrada",
+	"Failed to hide message":                         "No se pudo ocultar el mensaje",
+	"Failed to unhide message":                       "No se pudo volver a mostrar el mensaje",
+	"Failed to reject message":                       "No se pudo rechazar el mensaje",
+	"You have been blocked from this guestbook":      "Has sido bloqueado en este libro de visitas",
+	"Failed to get blocked authors":                  "No se pudieron obtener los autores bloqueados",
+	"Failed to block author":                         "No se pudo bloquear al autor",
+	"You can't block yourself":                       "No puedes bloquearte a ti mismo",
+	"Failed to unblock author":                       "No se pudo desbloquear al autor",
+	"Author is not blocked":                          "El autor no está bloqueado",
+
+	"You can't like your own message":                "No puedes dar me gusta a tu propio mensaje",
+	"You can't dislike your own message":             "No puedes dar no me gusta a tu propio mensaje",
+	"You have already liked this message":            "Ya diste me gusta a este mensaje",
+	"You have already disliked this message":         "Ya diste no me gusta a este mensaje",
+	"You have already liked message":                 "Ya diste me gusta a este mensaje",
+	"You have not liked this message":                "No has dado me gusta a este mensaje",
+	"You can only like your own message":             "Solo puedes dar me gusta a mensajes de tu propio libro de visitas",
+	"You can only remove like from your own message": "Solo puedes quitar el me gusta de mensajes de tu propio libro de visitas",
+	"Message not liked":                              "No has dado me gusta a este mensaje",
+	"Message not disliked":                           "No has dado no me gusta a este mensaje",
+	"Failed to like message":                         "No se pudo dar me gusta al mensaje",
+	"Failed to remove like":                          "No se pudo quitar el me gusta",
+	"Failed to dislike message":                      "No se pudo dar no me gusta al mensaje",
+	"Failed to remove dislike":                       "No se pudo quitar el no me gusta",
+	"Vote must be -1, 0 or 1":                        "El voto debe ser -1, 0 o 1",
+	"You can't vote on your own message":             "No puedes votar tu propio mensaje",
+	"Failed to vote":                                 "No se pudo votar",
+	"Unknown reaction":                               "Reacción desconocida",
+	"Reaction not allowed on this guestbook":         "Esta reacción no está permitida en este libro de visitas",
+	"Unknown reactions mode":                         "Modo de reacciones desconocido",
+	"Dislikes are turned off on this guestbook":      "Los «no me gusta» están desactivados en este libro de visitas",
+	"Reactions are turned off on this guestbook":     "Las reacciones están desactivadas en este libro de visitas",
+	"You can't react to your own message":            "No puedes reaccionar a tu propio mensaje",
+	"Failed to add reaction":                         "No se pudo añadir la reacción",
+	"Failed to remove reaction":                      "No se pudo quitar la reacción",
+	"Failed to get reactions":                        "No se pudieron obtener las reacciones",
+
+	"Failed to get settings":    "No se pudo obtener la configuración",
+	"Failed to update settings": "No se pudo actualizar la configuración",
+	"Failed to render image":    "No se pudo generar la imagen",
+	"Unknown theme":             "Tema desconocido",
+	"Invalid color":             "Color no válido",
+	"Invalid color scheme":      "Esquema de color no válido",
+	"Unknown layout":            "Diseño desconocido",
+	"Invalid limit":             "Límite no válido",
+	"Image is too large":        "La imagen es demasiado grande",
+	"Invalid offset":            "Desplazamiento no válido",
+	"Unknown mode":              "Modo desconocido",
+	"Invalid interval":          "Intervalo no válido",
+	"Invalid timestamps":        "timestamps no válido",
+	"Unknown sort":              "Orden desconocido",
+	"Unknown moderation mode":   "Modo de moderación desconocido",
+	"Too many blocked words":    "Demasiadas palabras bloqueadas",
+	"Invalid cursor":            "Cursor no válido",
+	"Invalid starred_only":      "Valor de starred_only no válido",
+	"Invalid min_score":         "Valor de min_score no válido",
+	"Unknown report status":     "Estado de denuncia desconocido",
+	"Unsupported language":      "Idioma no compatible",
+}
@@ -0,0 +1,89 @@
+// Package i18n translates user-facing strings. Messages are keyed by their
+// English text, so untranslated strings fall back to English.
+package i18n
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const Default = "en"
+
+var catalogs = map[string]map[string]string{
+	"en": {},
+	"ko": ko,
+	"ja": ja,
+	"es": es,
+}
+
+var fontFamilies = map[string]string{
+	"ko": `-apple-system, BlinkMacSystemFont, "Apple SD Gothic Neo", "Malgun Gothic", "Noto Sans KR", "Noto Sans CJK KR", "Segoe UI", Roboto, sans-serif`,
+	"ja": `-apple-system, BlinkMacSystemFont, "Hiragino Sans", "Hiragino Kaku Gothic ProN", "Yu Gothic", Meiryo, "Noto Sans JP", "Noto Sans CJK JP", "Segoe UI", Roboto, sans-serif`,
+}
+
+const defaultFontFamily = `-apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif`
+
+// Match returns the supported language for a tag such as "ko" or "ko-KR".
+func Match(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if _, ok := catalogs[tag]; !ok {
+		return "", false
+	}
+	return tag, true
+}
+
+// T returns msg translated into lang.
+func T(lang, msg string) string {
+	if s, ok := catalogs[lang][msg]; ok {
+		return s
+	}
+	return msg
+}
+
+// Tf translates format and then formats it with args.
+func Tf(lang, format string, args ...any) string {
+	return fmt.Sprintf(T(lang, format), args...)
+}
+
+// FontFamily returns a CSS font stack suited to the language's script.
+func FontFamily(lang string) string {
+	if f, ok := fontFamilies[lang]; ok {
+		return f
+	}
+	return defaultFontFamily
+}
+
+// FromAcceptLanguage picks the most preferred supported language from an
+// Accept-Language header.
+func FromAcceptLanguage(header string) string {
+	best, bestQ := Default, 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if lang, ok := Match(tag); ok && q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
+
+// FromRequest returns the caller's language: the lang query parameter if it
+// names a supported language, otherwise the Accept-Language header.
+func FromRequest(r *http.Request) string {
+	if lang, ok := Match(r.URL.Query().Get("lang")); ok {
+		return lang
+	}
+	return FromAcceptLanguage(r.Header.Get("Accept-Language"))
+}
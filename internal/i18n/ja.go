@@ -0,0 +1,137 @@
+package i18n
+
+var ja = map[string]string{
+	// Guestbook images
+	"GUESTBOOK":                             "ゲストブック",
+	"STARRED":                               "スター付き",
+	"No messages yet":                       "まだメッセージはありません",
+	"No starred messages yet":               "スター付きのメッセージはまだありません",
+	"+ %d more — view all":                  "ほか %d 件 — すべて表示",
+	"just now":                              "たった今",
+	"1 minute ago":                          "1分前",
+	"%d minutes ago":                        "%d分前",
+	"1 hour ago":                            "1時間前",
+	"%d hours ago":                          "%d時間前",
+	"1 day ago":                             "1日前",
+	"%d days ago":                           "%d日前",
+	"1 month ago":                           "1か月前",
+	"%d months ago":                         "%dか月前",
+	"1 year ago":                            "1年前",
+	"%d years ago":                          "%d年前",
+	"guestbook":                             "ゲストブック",
+	"unclaimed":                             "未登録",
+	"1 message":                             "1 件のメッセージ",
+	"%d messages":                           "%d 件のメッセージ",
+	"This profile hasn't been claimed yet.": "このプロフィールはまだ登録されていません。",
+	"Visit github-profile-guestbook.injun.dev/%s to claim this profile.": "github-profile-guestbook.injun.dev/%s にアクセスしてこのプロフィールを登録してください。",
+
+	// API errors
+	"Unauthorized":                                   "ログインが必要です",
+	"rate limit exceeded":                            "リクエストが多すぎます。しばらくしてから再度お試しください",
+	"GitHub user not found":                          "GitHub ユーザーが見つかりません",
+	"Failed to get user":                             "ユーザー情報を取得できませんでした",
+	"Failed to get users":                            "ユーザー一覧を取得できませんでした",
+	"Message not found":                              "メッセージが見つかりません",
+	"Invalid Message ID":                             "無効なメッセージ ID です",
+	"Content not provided":                           "内容を入力してください",
+	"Invalid content":                                "無効な内容です",
+	"Links are not allowed":                          "リンクは使用できません",
+	"Only GitHub links are allowed":                  "GitHub のリンクのみ使用できます",
+	"Message contains blocked words":                 "禁止されている単語が含まれています",
+	"Message was rejected by the content filter":     "コンテンツフィルターによって拒否されました",
+	"user already has a message":                     "すでにメッセージを投稿しています",
+	"Failed to create message":                       "メッセージを投稿できませんでした",
+	"Failed to get messages":                         "メッセージを取得できませんでした",
+	"Failed to delete message":                       "メッセージを削除できませんでした",
+	"Failed to edit message":                         "メッセージを編集できませんでした",
+	"Edit window has expired":                        "編集できる期間が過ぎています",
+	"Failed to get revisions":                        "編集履歴を取得できませんでした",
+	"You can't view this message's revisions":        "このメッセージの編集履歴は閲覧できません",
+	"Only the guestbook owner can reply to messages": "返信できるのはゲストブックの所有者のみです",
+	"Message already has a reply":                    "このメッセージにはすでに返信があります",
+	"Reply not found":                                "返信が見つかりません",
+	"Failed to create reply":                         "返信の作成に失敗しました",
+	"Failed to edit reply":                           "返信の編集に失敗しました",
+	"Failed to delete reply":                         "返信の削除に失敗しました",
+	"Failed to get pending messages":                 "承認待ちのメッセージを取得できませんでした",
+	"Pending message not found":                      "承認待ちのメッセージが見つかりません",
+	"Failed to approve message":                      "メッセージを承認できませんでした",
+	"Failed to pin messages":                         "メッセージを固定できませんでした",
+	"Too many pinned messages":                       "固定されたメッセージが多すぎます",
+	"Duplicate message ID":                           "メッセージ ID が重複しています",
+	"Reason not provided":                            "通報理由を入力してください",
+	"Reason is too long":                             "通報理由が長すぎます",
+	"Removal reason is too long":                     "削除理由が長すぎます",
+	"Message IDs not provided":                       "メッセージIDが指定されていません",
+	"Too many messages":                              "メッセージが多すぎます",
+	"Failed to delete messages":                      "メッセージの削除に失敗しました",
+	"Failed to get removed messages":                 "削除したメッセージの取得に失敗しました",
+	"Message was removed by the guestbook owner":     "ゲストブックの持ち主が削除したメッセージです",
+	"Failed to report message":                       "メッセージを通報できませんでした",
+	"You can't report your own message":              "自分のメッセージは通報できません",
+	"You already reported this message":              "このメッセージはすでに通報済みです",
+	"Admin access required":                          "管理者権限が必要です",
+	"Failed to get reports":                          "通報一覧を取得できませんでした",
+	"Invalid report ID":                              "無効な通報 ID です",
+	"Unknown action":                                 "不明な操作です",
+	"Failed to resolve report":                       "通報を処理できませんでした",
+	"Report not found":                               "通報が見つかりません",
+	"Failed to hide message":                         "メッセージを非表示にできませんでした",
+	"Failed to unhide message":                       "メッセージの非表示を解除できませんでした",
+	"Failed to reject message":                       "メッセージを却下できませんでした",
+	"You have been blocked from this guestbook":      "このゲストブックからブロックされています",
+	"Failed to get blocked authors":                  "ブロックしたユーザーを取得できませんでした",
+	"Failed to block author":                         "ユーザーをブロックできませんでした",
+	"You can't block yourself":                       "自分自身はブロックできません",
+	"Failed to unblock author":                       "ブロックを解除できませんでした",
+	"Author is not blocked":                          "このユーザーはブロックされていません",
+
+	"You can't like your own message":                "自分のメッセージにはいいねできません",
+	"You can't dislike your own message":             "自分のメッセージは低評価できません",
+	"You have already liked this message":            "このメッセージにはすでにいいねしています",
+	"You have already disliked this message":         "このメッセージはすでに低評価しています",
+	"You have already liked message":                 "このメッセージにはすでにいいねしています",
+	"You have not liked this message":                "このメッセージにはいいねしていません",
+	"You can only like your own message":             "自分のゲストブックのメッセージにのみいいねできます",
+	"You can only remove like from your own message": "自分のゲストブックのメッセージのみいいねを取り消せます",
+	"Message not liked":                              "このメッセージにはいいねしていません",
+	"Message not disliked":                           "このメッセージは低評価していません",
+	"Failed to like message":                         "いいねできませんでした",
+	"Failed to remove like":                          "いいねを取り消せませんでした",
+	"Failed to dislike message":                      "低評価できませんでした",
+	"Failed to remove dislike":                       "低評価を取り消せませんでした",
+	"Vote must be -1, 0 or 1":                        "投票の値は -1、0、1 のいずれかです",
+	"You can't vote on your own message":             "自分のメッセージには投票できません",
+	"Failed to vote":                                 "投票に失敗しました",
+	"Unknown reaction":                               "不明なリアクションです",
+	"Reaction not allowed on this guestbook":         "このゲストブックでは使えないリアクションです",
+	"Unknown reactions mode":                         "不明なリアクションモードです",
+	"Dislikes are turned off on this guestbook":      "このゲストブックでは低評価が無効になっています",
+	"Reactions are turned off on this guestbook":     "このゲストブックではリアクションが無効になっています",
+	"You can't react to your own message":            "自分のメッセージにはリアクションできません",
+	"Failed to add reaction":                         "リアクションの追加に失敗しました",
+	"Failed to remove reaction":                      "リアクションの取り消しに失敗しました",
+	"Failed to get reactions":                        "リアクションの取得に失敗しました",
+
+	"Failed to get settings":    "設定を取得できませんでした",
+	"Failed to update settings": "設定を保存できませんでした",
+	"Failed to render image":    "画像を生成できませんでした",
+	"Unknown theme":             "不明なテーマです",
+	"Invalid color":             "無効な色です",
+	"Invalid color scheme":      "無効なカラースキームです",
+	"Unknown layout":            "不明なレイアウトです",
+	"Invalid limit":             "無効な limit です",
+	"Image is too large":        "画像が大きすぎます",
+	"Invalid offset":            "無効な offset です",
+	"Unknown mode":              "不明なモードです",
+	"Invalid interval":          "無効な interval です",
+	"Invalid timestamps":        "無効な timestamps です",
+	"Unknown sort":              "不明な並び順です",
+	"Unknown moderation mode":   "不明な承認モードです",
+	"Too many blocked words":    "禁止ワードが多すぎます",
+	"Invalid cursor":            "無効なカーソルです",
+	"Invalid starred_only":      "無効な starred_only です",
+	"Invalid min_score":         "無効な min_score です",
+	"Unknown report status":     "不明な通報ステータスです",
+	"Unsupported language":      "サポートされていない言語です",
+}
@@ -0,0 +1,137 @@
+package i18n
+
+var ko = map[string]string{
+	// Guestbook images
+	"GUESTBOOK":                             "방명록",
+	"STARRED":                               "별표",
+	"No messages yet":                       "아직 메시지가 없습니다",
+	"No starred messages yet":               "아직 별표한 메시지가 없습니다",
+	"+ %d more — view all":                  "+ %d개 더 — 전체 보기",
+	"just now":                              "방금 전",
+	"1 minute ago":                          "1분 전",
+	"%d minutes ago":                        "%d분 전",
+	"1 hour ago":                            "1시간 전",
+	"%d hours ago":                          "%d시간 전",
+	"1 day ago":                             "1일 전",
+	"%d days ago":                           "%d일 전",
+	"1 month ago":                           "1개월 전",
+	"%d months ago":                         "%d개월 전",
+	"1 year ago":                            "1년 전",
+	"%d years ago":                          "%d년 전",
+	"guestbook":                             "방명록",
+	"unclaimed":                             "미등록",
+	"1 message":                             "메시지 1개",
+	"%d messages":                           "메시지 %d개",
+	"This profile hasn't been claimed yet.": "아직 등록되지 않은 프로필입니다.",
+	"Visit github-profile-guestbook.injun.dev/%s to claim this profile.": "github-profile-guestbook.injun.dev/%s 에서 이 프로필을 등록하세요.",
+
+	// API errors
+	"Unauthorized":                                   "인증이 필요합니다",
+	"rate limit exceeded":                            "요청이 너무 많습니다. 잠시 후 다시 시도하세요",
+	"GitHub user not found":                          "GitHub 사용자를 찾을 수 없습니다",
+	"Failed to get user":                             "사용자 정보를 불러오지 못했습니다",
+	"Failed to get users":                            "사용자 목록을 불러오지 못했습니다",
+	"Message not found":                              "메시지를 찾을 수 없습니다",
+	"Invalid Message ID":                             "올바르지 않은 메시지 ID입니다",
+	"Content not provided":                           "내용을 입력하세요",
+	"Invalid content":                                "올바르지 않은 내용입니다",
+	"Links are not allowed":                          "링크는 사용할 수 없습니다",
+	"Only GitHub links are allowed":                  "GitHub 링크만 사용할 수 있습니다",
+	"Message contains blocked words":                 "금지된 단어가 포함되어 있습니다",
+	"Message was rejected by the content filter":     "콘텐츠 필터에 의해 거부된 메시지입니다",
+	"user already has a message":                     "이미 메시지를 남겼습니다",
+	"Failed to create message":                       "메시지를 작성하지 못했습니다",
+	"Failed to get messages":                         "메시지를 불러오지 못했습니다",
+	"Failed to delete message":                       "메시지를 삭제하지 못했습니다",
+	"Failed to edit message":                         "메시지를 수정하지 못했습니다",
+	"Edit window has expired":                        "수정 가능한 시간이 지났습니다",
+	"Failed to get revisions":                        "수정 기록을 불러오지 못했습니다",
+	"You can't view this message's revisions":        "이 메시지의 수정 기록을 볼 수 없습니다",
+	"Only the guestbook owner can reply to messages": "방명록 주인만 답글을 달 수 있습니다",
+	"Message already has a reply":                    "이미 답글이 달린 메시지입니다",
+	"Reply not found":                                "답글을 찾을 수 없습니다",
+	"Failed to create reply":                         "답글을 작성하지 못했습니다",
+	"Failed to edit reply":                           "답글을 수정하지 못했습니다",
+	"Failed to delete reply":                         "답글을 삭제하지 못했습니다",
+	"Failed to get pending messages":                 "승인 대기 중인 메시지를 불러오지 못했습니다",
+	"Pending message not found":                      "승인 대기 중인 메시지를 찾을 수 없습니다",
+	"Failed to approve message":                      "메시지를 승인하지 못했습니다",
+	"Failed to pin messages":                         "메시지를 고정하지 못했습니다",
+	"Too many pinned messages":                       "고정된 메시지가 너무 많습니다",
+	"Duplicate message ID":                           "중복된 메시지 ID입니다",
+	"Reason not provided":                            "신고 사유를 입력해 주세요",
+	"Reason is too long":                             "신고 사유가 너무 깁니다",
+	"Removal reason is too long":                     "삭제 사유가 너무 깁니다",
+	"Message IDs not provided":                       "메시지 ID가 없습니다",
+	"Too many messages":                              "메시지가 너무 많습니다",
+	"Failed to delete messages":                      "메시지 삭제에 실패했습니다",
+	"Failed to get removed messages":                 "삭제된 메시지를 가져오지 못했습니다",
+	"Message was removed by the guestbook owner":     "방명록 주인이 삭제한 메시지입니다",
+	"Failed to report message":                       "메시지를 신고하지 못했습니다",
+	"You can't report your own message":              "자신의 메시지는 신고할 수 없습니다",
+	"You already reported this message":              "이미 신고한 메시지입니다",
+	"Admin access required":                          "관리자 권한이 필요합니다",
+	"Failed to get reports":                          "신고 목록을 불러오지 못했습니다",
+	"Invalid report ID":                              "올바르지 않은 신고 ID입니다",
+	"Unknown action":                                 "알 수 없는 작업입니다",
+	"Failed to resolve report":                       "신고를 처리하지 못했습니다",
+	"Report not found":                               "신고를 찾을 수 없습니다",
+	"Failed to hide message":                         "메시지를 숨기지 못했습니다",
+	"Failed to unhide message":                       "메시지 숨김을 해제하지 못했습니다",
+	"Failed to reject message":                       "메시지를 거절하지 못했습니다",
+	"You have been blocked from this guestbook":      "이 방명록에서 차단되었습니다",
+	"Failed to get blocked authors":                  "차단한 사용자 목록을 불러오지 못했습니다",
+	"Failed to block author":                         "사용자를 차단하지 못했습니다",
+	"You can't block yourself":                       "자기 자신은 차단할 수 없습니다",
+	"Failed to unblock author":                       "차단을 해제하지 못했습니다",
+	"Author is not blocked":                          "차단된 사용자가 아닙니다",
+
+	"You can't like your own message":                "자신의 메시지에는 좋아요를 누를 수 없습니다",
+	"You can't dislike your own message":             "자신의 메시지에는 싫어요를 누를 수 없습니다",
+	"You have already liked this message":            "이미 좋아요를 누른 메시지입니다",
+	"You have already disliked this message":         "이미 싫어요를 누른 메시지입니다",
+	"You have already liked message":                 "이미 좋아요를 누른 메시지입니다",
+	"You have not liked this message":                "좋아요를 누르지 않은 메시지입니다",
+	"You can only like your own message":             "자신의 방명록에 있는 메시지에만 좋아요를 누를 수 있습니다",
+	"You can only remove like from your own message": "자신의 방명록에 있는 메시지만 좋아요를 취소할 수 있습니다",
+	"Message not liked":                              "좋아요를 누르지 않은 메시지입니다",
+	"Message not disliked":                           "싫어요를 누르지 않은 메시지입니다",
+	"Failed to like message":                         "좋아요를 누르지 못했습니다",
+	"Failed to remove like":                          "좋아요를 취소하지 못했습니다",
+	"Failed to dislike message":                      "싫어요를 누르지 못했습니다",
+	"Failed to remove dislike":                       "싫어요를 취소하지 못했습니다",
+	"Vote must be -1, 0 or 1":                        "투표 값은 -1, 0, 1 중 하나여야 합니다",
+	"You can't vote on your own message":             "자신의 메시지에는 투표할 수 없습니다",
+	"Failed to vote":                                 "투표하지 못했습니다",
+	"Unknown reaction":                               "알 수 없는 반응입니다",
+	"Reaction not allowed on this guestbook":         "이 방명록에서 사용할 수 없는 반응입니다",
+	"Unknown reactions mode":                         "알 수 없는 반응 모드입니다",
+	"Dislikes are turned off on this guestbook":      "이 방명록은 싫어요를 사용하지 않습니다",
+	"Reactions are turned off on this guestbook":     "이 방명록은 반응을 사용하지 않습니다",
+	"You can't react to your own message":            "자신의 메시지에는 반응할 수 없습니다",
+	"Failed to add reaction":                         "반응을 추가하지 못했습니다",
+	"Failed to remove reaction":                      "반응을 취소하지 못했습니다",
+	"Failed to get reactions":                        "반응 목록을 가져오지 못했습니다",
+
+	"Failed to get settings":    "설정을 불러오지 못했습니다",
+	"Failed to update settings": "설정을 저장하지 못했습니다",
+	"Failed to render image":    "이미지를 생성하지 못했습니다",
+	"Unknown theme":             "알 수 없는 테마입니다",
+	"Invalid color":             "올바르지 않은 색상입니다",
+	"Invalid color scheme":      "올바르지 않은 색상 모드입니다",
+	"Unknown layout":            "알 수 없는 레이아웃입니다",
+	"Invalid limit":             "올바르지 않은 limit 값입니다",
+	"Image is too large":        "이미지가 너무 큽니다",
+	"Invalid offset":            "올바르지 않은 offset 값입니다",
+	"Unknown mode":              "알 수 없는 모드입니다",
+	"Invalid interval":          "올바르지 않은 interval 값입니다",
+	"Invalid timestamps":        "올바르지 않은 timestamps 값입니다",
+	"Unknown sort":              "알 수 없는 정렬 방식입니다",
+	"Unknown moderation mode":   "알 수 없는 검토 방식입니다",
+	"Too many blocked words":    "금지어가 너무 많습니다",
+	"Invalid cursor":            "올바르지 않은 커서입니다",
+	"Invalid starred_only":      "올바르지 않은 starred_only 값입니다",
+	"Invalid min_score":         "올바르지 않은 min_score 값입니다",
+	"Unknown report status":     "알 수 없는 신고 상태입니다",
+	"Unsupported language":      "지원하지 않는 언어입니다",
+}
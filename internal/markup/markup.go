@@ -0,0 +1,240 @@
+// Package markup parses the small formatting syntax allowed in guestbook
+// messages: **bold**, *italic* or _italic_, `code`, @mentions and links to
+// GitHub. Everything else, including HTML, stays plain text, and links are
+// only ever built from what the parser accepted.
+package markup
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Kind string
+
+const (
+	Text    Kind = "text"
+	Bold    Kind = "bold"
+	Italic  Kind = "italic"
+	Code    Kind = "code"
+	Mention Kind = "mention"
+	Link    Kind = "link"
+)
+
+// Node is an element of the token tree. Bold and Italic nodes hold Children
+// and the other kinds hold Text. Mention and Link nodes also carry the Href
+// they point to; a mention points to the user's GitHub profile, which is
+// absolute so it also works inside rendered images.
+type Node struct {
+	Kind     Kind   `json:"type"`
+	Text     string `json:"text,omitempty"`
+	Href     string `json:"href,omitempty"`
+	Children []Node `json:"children,omitempty"`
+}
+
+// escapable are the characters a backslash makes literal.
+const escapable = "\\*_`@"
+
+var (
+	mentionPattern = regexp.MustCompile(`^@([A-Za-z0-9](?:-?[A-Za-z0-9]){0,38})`)
+	urlPattern     = regexp.MustCompile(`^(?i)https?://[^\s<>"'` + "`" + `]+`)
+)
+
+// Parse turns message content into a token tree.
+func Parse(s string) []Node {
+	p := &parser{src: s}
+	nodes, _ := p.inlines("", false, false)
+	return nodes
+}
+
+type parser struct {
+	src string
+	pos int
+}
+
+// inlines parses until closing, or the end of the input if closing is empty.
+// Emphasis doesn't nest in itself, so inBold and inItalic say which kinds are
+// already open. ok is false if closing wasn't found on the current line.
+func (p *parser) inlines(closing string, inBold, inItalic bool) (nodes []Node, ok bool) {
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, Node{Kind: Text, Text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for p.pos < len(p.src) {
+		rest := p.src[p.pos:]
+		if closing != "" {
+			if rest[0] == '\n' {
+				return nil, false
+			}
+			// "**" inside *italic* opens bold rather than closing the italic.
+			opensBold := closing == "*" && !inBold && strings.HasPrefix(rest, "**")
+			if strings.HasPrefix(rest, closing) && p.canClose(closing) && !opensBold {
+				flush()
+				p.pos += len(closing)
+				return nodes, true
+			}
+		}
+
+		switch {
+		case rest[0] == '\\' && len(rest) > 1 && strings.IndexByte(escapable, rest[1]) >= 0:
+			text.WriteByte(rest[1])
+			p.pos += 2
+			continue
+
+		case rest[0] == '`':
+			if end := strings.IndexByte(rest[1:], '`'); end > 0 && !strings.Contains(rest[1:1+end], "\n") {
+				flush()
+				nodes = append(nodes, Node{Kind: Code, Text: rest[1 : 1+end]})
+				p.pos += end + 2
+				continue
+			}
+
+		case rest[0] == '@' && !p.afterWord():
+			if m := mentionPattern.FindStringSubmatch(rest); m != nil && !continuesLogin(rest[len(m[0]):]) {
+				flush()
+				nodes = append(nodes, Node{Kind: Mention, Text: m[0], Href: "https://github.com/" + m[1]})
+				p.pos += len(m[0])
+				continue
+			}
+
+		case (rest[0] == 'h' || rest[0] == 'H') && !p.afterWord():
+			if raw := urlPattern.FindString(rest); raw != "" {
+				raw = trimURL(raw)
+				if href, ok := githubLink(raw); ok {
+					flush()
+					nodes = append(nodes, Node{Kind: Link, Text: raw, Href: href})
+				} else {
+					// Other links stay text, and so does any markup in them.
+					text.WriteString(raw)
+				}
+				p.pos += len(raw)
+				continue
+			}
+
+		case strings.HasPrefix(rest, "**") && !inBold:
+			if node, ok := p.emphasis(Bold, "**", inItalic); ok {
+				flush()
+				nodes = append(nodes, node)
+				continue
+			}
+
+		case (rest[0] == '*' || rest[0] == '_' && !p.afterWord()) && !inItalic:
+			if node, ok := p.emphasis(Italic, rest[:1], inBold); ok {
+				flush()
+				nodes = append(nodes, node)
+				continue
+			}
+		}
+
+		_, size := utf8.DecodeRuneInString(rest)
+		text.WriteString(rest[:size])
+		p.pos += size
+	}
+
+	if closing != "" {
+		return nil, false
+	}
+	flush()
+	return nodes, true
+}
+
+// emphasis parses a Bold or Italic node delimited by delim at the current
+// position. On failure the position is left unchanged.
+func (p *parser) emphasis(kind Kind, delim string, inOther bool) (Node, bool) {
+	start := p.pos
+	p.pos += len(delim)
+	if p.pos >= len(p.src) || startsWithSpace(p.src[p.pos:]) {
+		p.pos = start
+		return Node{}, false
+	}
+
+	var children []Node
+	var ok bool
+	if kind == Bold {
+		children, ok = p.inlines(delim, true, inOther)
+	} else {
+		children, ok = p.inlines(delim, inOther, true)
+	}
+	if !ok || len(children) == 0 {
+		p.pos = start
+		return Node{}, false
+	}
+	return Node{Kind: kind, Children: children}, true
+}
+
+// canClose reports whether delim at the current position can end emphasis:
+// it must follow text rather than a space, and an underscore must not be
+// inside a word, as in snake_case.
+func (p *parser) canClose(delim string) bool {
+	before, _ := utf8.DecodeLastRuneInString(p.src[:p.pos])
+	if before == utf8.RuneError || unicode.IsSpace(before) {
+		return false
+	}
+	if delim == "_" {
+		after, _ := utf8.DecodeRuneInString(p.src[p.pos+1:])
+		return !isWordRune(after)
+	}
+	return true
+}
+
+// afterWord reports whether the current position directly follows a word
+// character, which rules out mentions (e.g. in email addresses), links and
+// underscore emphasis.
+func (p *parser) afterWord() bool {
+	before, _ := utf8.DecodeLastRuneInString(p.src[:p.pos])
+	return isWordRune(before)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func startsWithSpace(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsSpace(r)
+}
+
+// continuesLogin reports whether rest would make a mention longer than a
+// GitHub login can be, or continue it with characters logins can't contain.
+func continuesLogin(rest string) bool {
+	r, _ := utf8.DecodeRuneInString(rest)
+	return r == '-' || isWordRune(r)
+}
+
+// trimURL drops trailing punctuation that more likely ends the sentence or
+// closes surrounding markup than belongs to the link.
+func trimURL(raw string) string {
+	for raw != "" {
+		last := raw[len(raw)-1]
+		switch {
+		case strings.IndexByte(".,;:!?*_", last) >= 0:
+		case last == ')' && strings.Count(raw, "(") < strings.Count(raw, ")"):
+		default:
+			return raw
+		}
+		raw = raw[:len(raw)-1]
+	}
+	return raw
+}
+
+// githubLink returns the href for raw if it is an http(s) link to GitHub.
+func githubLink(raw string) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || u.User != nil {
+		return "", false
+	}
+	if scheme := strings.ToLower(u.Scheme); scheme != "http" && scheme != "https" {
+		return "", false
+	}
+	host := strings.ToLower(u.Hostname())
+	if host != "github.com" && !strings.HasSuffix(host, ".github.com") {
+		return "", false
+	}
+	return u.String(), true
+}
@@ -0,0 +1,49 @@
+package markup
+
+import "strings"
+
+// Span is a run of text with the formatting of the nodes it sits in.
+type Span struct {
+	Text   string
+	Bold   bool
+	Italic bool
+	Code   bool
+	Href   string
+}
+
+// Spans flattens a token tree into the runs of text a renderer draws.
+func Spans(nodes []Node) []Span {
+	return appendSpans(nil, nodes, Span{})
+}
+
+func appendSpans(spans []Span, nodes []Node, outer Span) []Span {
+	for _, n := range nodes {
+		span := outer
+		switch n.Kind {
+		case Bold:
+			span.Bold = true
+			spans = appendSpans(spans, n.Children, span)
+			continue
+		case Italic:
+			span.Italic = true
+			spans = appendSpans(spans, n.Children, span)
+			continue
+		case Code:
+			span.Code = true
+		case Mention, Link:
+			span.Href = n.Href
+		}
+		span.Text = n.Text
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+// Plain returns the text of a token tree without its markup.
+func Plain(nodes []Node) string {
+	var b strings.Builder
+	for _, s := range Spans(nodes) {
+		b.WriteString(s.Text)
+	}
+	return b.String()
+}
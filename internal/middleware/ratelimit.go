@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/in-jun/github-profile-guestbook/internal/i18n"
 )
 
 type bucket struct {
@@ -48,7 +49,7 @@ func (rl *RateLimiter) Handler() gin.HandlerFunc {
 		b.count++
 		if b.count > rl.limit {
 			rl.mu.Unlock()
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": i18n.T(i18n.FromRequest(c.Request), "rate limit exceeded")})
 			return
 		}
 
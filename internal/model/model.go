@@ -1,21 +1,142 @@
 package model
 
+import (
+	"time"
+
+	"github.com/in-jun/github-profile-guestbook/internal/markup"
+)
+
 type MessageResponse struct {
-	ID           int64  `json:"id"`
-	Author       string `json:"author"`
-	Content      string `json:"content"`
-	IsOwnerLiked bool   `json:"is_owner_liked"`
-	IsLiked      bool   `json:"is_liked"`
-	IsDisliked   bool   `json:"is_disliked"`
-	Likes        int    `json:"likes"`
-	Dislikes     int    `json:"dislikes"`
+	ID           int64           `json:"id"`
+	Author       string          `json:"author"`
+	Content      string          `json:"content"`
+	Rendered     []markup.Node   `json:"rendered"`
+	IsOwnerLiked bool            `json:"is_owner_liked"`
+	IsLiked      bool            `json:"is_liked"`
+	IsDisliked   bool            `json:"is_disliked"`
+	Likes        int             `json:"likes"`
+	Dislikes     int             `json:"dislikes"`
+	Reactions    []ReactionCount `json:"reactions"`
+
+	// EnabledReactions are the reactions visitors can add on this guestbook.
+	EnabledReactions []string `json:"enabled_reactions"`
+
+	Status      string        `json:"status"`
+	PinPosition *int          `json:"pin_position"`
+	Hidden      bool          `json:"hidden"`
+	CreatedAt   time.Time     `json:"created_at"`
+	EditedAt    *time.Time    `json:"edited_at"`
+	Reply       *MessageReply `json:"reply"`
+}
+
+type MessageListResponse struct {
+	Items      []MessageResponse `json:"items"`
+	NextCursor *string           `json:"next_cursor"`
+	Total      int               `json:"total"`
+	Reactions  []string          `json:"reactions"`
+}
+
+// VoteResponse is a message's like and dislike counts after a vote, and the
+// caller's vote: 1, -1 or 0.
+type VoteResponse struct {
+	Likes    int `json:"likes"`
+	Dislikes int `json:"dislikes"`
+	Value    int `json:"value"`
+}
+
+// Reactor is one reaction in the list of who reacted to a message. User is
+// nil for dislikes when the owner hides dislikers.
+type Reactor struct {
+	User      *string   `json:"user"`
+	Emoji     string    `json:"emoji"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReactionCount is how many people reacted to a message with an emoji, and
+// whether the viewer is one of them.
+type ReactionCount struct {
+	Emoji   string `json:"emoji"`
+	Count   int    `json:"count"`
+	Reacted bool   `json:"reacted"`
+}
+
+type MessageReply struct {
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type PendingMessage struct {
+	ID        int64     `json:"id"`
+	Author    string    `json:"author"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BlockedAuthor struct {
+	Author       string    `json:"author"`
+	HideMessages bool      `json:"hide_messages"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type Report struct {
+	ID         int64      `json:"id"`
+	MessageID  *int64     `json:"message_id"`
+	Receiver   string     `json:"receiver"`
+	Author     string     `json:"author"`
+	Reporter   string     `json:"reporter"`
+	Reason     string     `json:"reason"`
+	Content    string     `json:"content"`
+	Hidden     bool       `json:"hidden"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+	Resolution *string    `json:"resolution"`
+}
+
+type MessageRemoval struct {
+	ID        int64     `json:"id"`
+	MessageID *int64    `json:"message_id"`
+	Author    string    `json:"author"`
+	Content   string    `json:"content"`
+	Reason    *string   `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MessageRevision struct {
+	ID        int64     `json:"id"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type SvgMessageModel struct {
-	ID           int64
-	Author       string
-	Content      string
-	Likes        int
-	Dislikes     int
-	IsOwnerLiked bool
+	ID             int64
+	Author         string
+	AuthorGitHubID int64
+	Content        string
+	Markup         []markup.Node
+	Likes          int
+	Dislikes       int
+	IsOwnerLiked   bool
+	Reactions      []ReactionCount
+	Reply          string
+	CreatedAt      time.Time
+}
+
+type GuestbookSettings struct {
+	Theme  string  `json:"theme"`
+	Bg     *string `json:"bg"`
+	Text   *string `json:"text"`
+	Border *string `json:"border"`
+	Accent *string `json:"accent"`
+
+	MaxMessages *int    `json:"max_messages"`
+	Language    *string `json:"language"`
+	Sort        *string `json:"sort"`
+	Moderation  *string `json:"moderation"`
+
+	BlockedWords []string `json:"blocked_words"`
+	Reactions    []string `json:"reactions"`
+
+	ReactionsMode *string `json:"reactions_mode"`
+	HideDislikers bool    `json:"hide_dislikers"`
 }